@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+	"github.com/CaioMtho/pinoql-mcp/internal/health"
+	"github.com/CaioMtho/pinoql-mcp/internal/migrate"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func databasePath() string {
+	if dbPath := os.Getenv("DB_PATH"); dbPath != "" {
+		return dbPath
+	}
+	return "./db/pinoql.sqlite"
+}
+
+func openDatabase() *sqlx.DB {
+	db, err := sqlx.Connect("sqlite3", databasePath())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	_, err = db.Exec("PRAGMA foreign_keys = ON")
+	if err != nil {
+		log.Fatalf("Failed to enable foreign keys: %v", err)
+	}
+
+	return db
+}
+
+// runMigrateCommand implements "pinoql migrate up|down|status".
+func runMigrateCommand(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: pinoql migrate up|down|status")
+		os.Exit(2)
+	}
+
+	cryptoManager := loadCryptoManager()
+
+	db := openDatabase()
+	defer func(db *sqlx.DB) {
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to close connection: %v", err)
+		}
+	}(db)
+
+	migrator, err := migrate.NewMigrator(db, cryptoManager)
+	if err != nil {
+		log.Fatalf("Failed to create migrator: %v", err)
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "status":
+		err = migrator.Status(ctx, os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q, expected up, down or status\n", args[0])
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// loadManagerConfig reads the limits on open target database pools:
+// MAX_OPEN_POOLS, MAX_OPEN_POOLS_PER_TENANT (0 means unlimited) and
+// POOL_IDLE_TIMEOUT (0 disables idle eviction), and the circuit breaker
+// settings CIRCUIT_BREAKER_THRESHOLD (0 disables it) and
+// CIRCUIT_BREAKER_COOLDOWN. Embedded database files must live in DATA_DIR
+// and may never be the control-plane database itself.
+func loadManagerConfig() connection.ManagerConfig {
+	cfg := connection.ManagerConfig{
+		MaxPools:          connection.DefaultMaxPools,
+		MaxPoolsPerTenant: connection.DefaultMaxPoolsPerTenant,
+		IdleTimeout:       connection.DefaultPoolIdleTimeout,
+		BreakerThreshold:  connection.DefaultBreakerThreshold,
+		BreakerCooldown:   connection.DefaultBreakerCooldown,
+		Files: connection.FilePolicy{
+			DataDir: connection.DefaultDataDir,
+			Deny:    []string{databasePath()},
+		},
+	}
+
+	var err error
+	if value := os.Getenv("MAX_OPEN_POOLS"); value != "" {
+		if cfg.MaxPools, err = strconv.Atoi(value); err != nil {
+			log.Fatalf("Invalid MAX_OPEN_POOLS: %v", err)
+		}
+	}
+	if value := os.Getenv("MAX_OPEN_POOLS_PER_TENANT"); value != "" {
+		if cfg.MaxPoolsPerTenant, err = strconv.Atoi(value); err != nil {
+			log.Fatalf("Invalid MAX_OPEN_POOLS_PER_TENANT: %v", err)
+		}
+	}
+	if value := os.Getenv("POOL_IDLE_TIMEOUT"); value != "" {
+		var timeout time.Duration
+		if timeout, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid POOL_IDLE_TIMEOUT: %v", err)
+		}
+		cfg.IdleTimeout = timeout
+	}
+	if value, ok := os.LookupEnv("DATA_DIR"); ok {
+		cfg.Files.DataDir = value
+	}
+	if value := os.Getenv("CIRCUIT_BREAKER_THRESHOLD"); value != "" {
+		if cfg.BreakerThreshold, err = strconv.Atoi(value); err != nil {
+			log.Fatalf("Invalid CIRCUIT_BREAKER_THRESHOLD: %v", err)
+		}
+	}
+	if value := os.Getenv("CIRCUIT_BREAKER_COOLDOWN"); value != "" {
+		if cfg.BreakerCooldown, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid CIRCUIT_BREAKER_COOLDOWN: %v", err)
+		}
+	}
+
+	return cfg
+}
+
+// loadHealthConfig reads HEALTH_CHECK_INTERVAL (0 disables the background
+// monitor) and HEALTH_CHECK_TIMEOUT.
+func loadHealthConfig() health.Config {
+	cfg := health.Config{
+		Interval: health.DefaultInterval,
+		Timeout:  health.DefaultTimeout,
+	}
+
+	var err error
+	if value := os.Getenv("HEALTH_CHECK_INTERVAL"); value != "" {
+		if cfg.Interval, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid HEALTH_CHECK_INTERVAL: %v", err)
+		}
+	}
+	if value := os.Getenv("HEALTH_CHECK_TIMEOUT"); value != "" {
+		if cfg.Timeout, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid HEALTH_CHECK_TIMEOUT: %v", err)
+		}
+	}
+
+	return cfg
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/base64"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/oidc"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/signing"
+	"github.com/CaioMtho/pinoql-mcp/internal/crypto"
+	"github.com/jmoiron/sqlx"
+)
+
+// loadCryptoManager builds the crypto manager from the KEY_PROVIDER setting:
+//
+//   - local (default): MASTER_KEY or MASTER_KEY_FILE wraps new DEKs and
+//     PREVIOUS_MASTER_KEYS, a comma-separated list, holds retired keys still
+//     needed to unwrap DEKs that have not been rotated yet.
+//   - keyring: KEYRING_FILE points to a JSON keyring.
+//   - vault: DEKs are wrapped by Vault Transit (VAULT_ADDR, VAULT_TOKEN or
+//     VAULT_TOKEN_FILE, VAULT_NAMESPACE, VAULT_TRANSIT_MOUNT and
+//     VAULT_TRANSIT_KEY). A local key, if still configured, is kept for
+//     decryption only so existing DEKs can be moved into Vault. The token
+//     needs update on the encrypt, decrypt and rewrap endpoints and read on
+//     the key itself, whose latest version rotate-keys rewraps DEKs to.
+func loadCryptoManager() *crypto.CryptoManager {
+	switch provider := os.Getenv("KEY_PROVIDER"); provider {
+	case "", "local":
+		return crypto.NewCryptoManagerWithProvider(loadLocalKeyring(true))
+
+	case "keyring":
+		path := os.Getenv("KEYRING_FILE")
+		if path == "" {
+			log.Fatal("KEYRING_FILE environment variable is required")
+		}
+		keyring, err := crypto.LoadKeyringFile(path)
+		if err != nil {
+			log.Fatalf("Failed to load keyring: %v", err)
+		}
+		return crypto.NewCryptoManagerWithProvider(keyring)
+
+	case "vault":
+		token := os.Getenv("VAULT_TOKEN")
+		if tokenFile := os.Getenv("VAULT_TOKEN_FILE"); tokenFile != "" {
+			data, err := os.ReadFile(tokenFile)
+			if err != nil {
+				log.Fatalf("Failed to read VAULT_TOKEN_FILE: %v", err)
+			}
+			token = strings.TrimSpace(string(data))
+		}
+
+		vault, err := crypto.NewVaultTransitProvider(crypto.VaultTransitConfig{
+			Address:   os.Getenv("VAULT_ADDR"),
+			Token:     token,
+			Namespace: os.Getenv("VAULT_NAMESPACE"),
+			Mount:     os.Getenv("VAULT_TRANSIT_MOUNT"),
+			KeyName:   os.Getenv("VAULT_TRANSIT_KEY"),
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure vault key provider: %v", err)
+		}
+
+		if keyring := loadLocalKeyring(false); keyring != nil {
+			return crypto.NewCryptoManagerWithProvider(vault, keyring)
+		}
+		return crypto.NewCryptoManagerWithProvider(vault)
+
+	default:
+		log.Fatalf("Unknown KEY_PROVIDER %q, expected local, keyring or vault", provider)
+		return nil
+	}
+}
+
+// loadLocalKeyring reads the master key from MASTER_KEY or MASTER_KEY_FILE
+// together with PREVIOUS_MASTER_KEYS. It returns nil when no key is set and
+// the key is not required.
+func loadLocalKeyring(required bool) *crypto.Keyring {
+	var masterKey []byte
+	var err error
+
+	switch {
+	case os.Getenv("MASTER_KEY_FILE") != "":
+		masterKey, err = crypto.LoadKeyFile(os.Getenv("MASTER_KEY_FILE"))
+		if err != nil {
+			log.Fatalf("Invalid MASTER_KEY_FILE: %v", err)
+		}
+	case os.Getenv("MASTER_KEY") != "":
+		masterKey, err = base64.StdEncoding.DecodeString(os.Getenv("MASTER_KEY"))
+		if err != nil {
+			log.Fatalf("Invalid MASTER_KEY: %v", err)
+		}
+	case required:
+		log.Fatal("MASTER_KEY environment variable is required")
+	default:
+		return nil
+	}
+
+	var previousKeys [][]byte
+	for _, keyB64 := range strings.Split(os.Getenv("PREVIOUS_MASTER_KEYS"), ",") {
+		keyB64 = strings.TrimSpace(keyB64)
+		if keyB64 == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(keyB64)
+		if err != nil {
+			log.Fatalf("Invalid key in PREVIOUS_MASTER_KEYS: %v", err)
+		}
+		previousKeys = append(previousKeys, key)
+	}
+
+	keyring, err := crypto.NewKeyring(masterKey, previousKeys...)
+	if err != nil {
+		log.Fatalf("Failed to create crypto manager: %v", err)
+	}
+
+	return keyring
+}
+
+// loadKeyManager configures JWT signing. JWT_SIGNING_ALG selects HS256 (the
+// default, signing with JWT_SECRET) or RS256, ES256 or EdDSA, which use
+// generated keys rotated every JWT_KEY_ROTATION_PERIOD and kept for
+// verification for JWT_KEY_RETENTION. JWT_SECRET remains optional with an
+// asymmetric algorithm, to keep verifying HS256 tokens issued before the
+// switch.
+func loadKeyManager(db *sqlx.DB, cryptoManager *crypto.CryptoManager) *signing.KeyManager {
+	algorithm := os.Getenv("JWT_SIGNING_ALG")
+	if algorithm == "" {
+		algorithm = signing.AlgHS256
+	}
+
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if algorithm == signing.AlgHS256 && jwtSecret == "" {
+		log.Fatal("JWT_SECRET environment variable is required")
+	}
+
+	cfg := signing.Config{
+		Algorithm:  algorithm,
+		HMACSecret: jwtSecret,
+	}
+
+	var err error
+	if period := os.Getenv("JWT_KEY_ROTATION_PERIOD"); period != "" {
+		if cfg.RotationPeriod, err = time.ParseDuration(period); err != nil {
+			log.Fatalf("Invalid JWT_KEY_ROTATION_PERIOD: %v", err)
+		}
+	}
+	if retention := os.Getenv("JWT_KEY_RETENTION"); retention != "" {
+		if cfg.Retention, err = time.ParseDuration(retention); err != nil {
+			log.Fatalf("Invalid JWT_KEY_RETENTION: %v", err)
+		}
+	}
+
+	keyManager, err := signing.NewKeyManager(signing.NewSigningKeyRepository(db, cryptoManager), cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT signing: %v", err)
+	}
+
+	return keyManager
+}
+
+// runRotateKeysCommand implements "pinoql rotate-keys": it re-wraps every
+// DEK under the active key, after which retired keys can be removed.
+func runRotateKeysCommand() {
+	cryptoManager := loadCryptoManager()
+
+	db := openDatabase()
+	defer func(db *sqlx.DB) {
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to close connection: %v", err)
+		}
+	}(db)
+
+	connDataRepo := connection_data.NewConnectionDataRepository(db, cryptoManager)
+
+	rewrapped, err := connDataRepo.RewrapDEKs()
+	if err != nil {
+		log.Fatalf("Failed to rotate keys: %v", err)
+	}
+
+	signingKeyRepo := signing.NewSigningKeyRepository(db, cryptoManager)
+
+	rewrappedSigningKeys, err := signingKeyRepo.RewrapDEKs()
+	if err != nil {
+		log.Fatalf("Failed to rotate keys: %v", err)
+	}
+
+	log.Printf("Re-wrapped %d DEKs under master key %s", rewrapped+rewrappedSigningKeys, cryptoManager.ActiveKeyID())
+}
+
+// loadOIDCVerifier configures trust in an external identity provider when
+// OIDC_ISSUER is set. OIDC_AUDIENCE is the audience PinoQL is registered
+// under and OIDC_CLAIM_MAPPING_FILE maps the provider's claims to tenants,
+// connections and permissions.
+func loadOIDCVerifier() *oidc.Verifier {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+
+	mappingFile := os.Getenv("OIDC_CLAIM_MAPPING_FILE")
+	if mappingFile == "" {
+		log.Fatal("OIDC_CLAIM_MAPPING_FILE environment variable is required when OIDC_ISSUER is set")
+	}
+	mapping, err := oidc.LoadMappingFile(mappingFile)
+	if err != nil {
+		log.Fatalf("Failed to load OIDC claim mapping: %v", err)
+	}
+
+	cfg := oidc.Config{
+		Issuer:   issuer,
+		Audience: os.Getenv("OIDC_AUDIENCE"),
+		Mapping:  mapping,
+	}
+	if ttl := os.Getenv("OIDC_JWKS_CACHE_TTL"); ttl != "" {
+		if cfg.JWKSCacheTTL, err = time.ParseDuration(ttl); err != nil {
+			log.Fatalf("Invalid OIDC_JWKS_CACHE_TTL: %v", err)
+		}
+	}
+
+	verifier, err := oidc.NewVerifier(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure OIDC: %v", err)
+	}
+
+	return verifier
+}
@@ -1,56 +1,56 @@
 package main
 
 import (
-	"encoding/base64"
-	"fmt"
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/admin"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/apikey"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/audit"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/middleware"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/oauth"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/signing"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/tenant"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/token"
-	"github.com/CaioMtho/pinoql-mcp/internal/crypto"
+	"github.com/CaioMtho/pinoql-mcp/internal/health"
+	mcptools "github.com/CaioMtho/pinoql-mcp/internal/mcp"
+	"github.com/CaioMtho/pinoql-mcp/internal/migrate"
 	"github.com/CaioMtho/pinoql-mcp/internal/routes"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: Error loading .env file: %v", err.Error())
 	}
 
-	masterKeyB64 := os.Getenv("MASTER_KEY")
-	if masterKeyB64 == "" {
-		log.Fatal("MASTER_KEY environment variable is required")
-	}
-
-	masterKey, err := base64.StdEncoding.DecodeString(masterKeyB64)
-	if err != nil {
-		log.Fatalf("Invalid MASTER_KEY: %v", err)
-	}
-
-	cryptoManager, err := crypto.NewCryptoManager(masterKey)
-	if err != nil {
-		fmt.Println("Decoded master key length:", len(masterKey))
-		log.Fatalf("Failed to create crypto manager: %v", err)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrateCommand(os.Args[2:])
+			return
+		case "rotate-keys":
+			runRotateKeysCommand()
+			return
+		}
 	}
 
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "./db/pinoql.sqlite"
-	}
+	cryptoManager := loadCryptoManager()
 
-	db, err := sqlx.Connect("sqlite3", dbPath)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
+	db := openDatabase()
 	defer func(db *sqlx.DB) {
 		err := db.Close()
 		if err != nil {
@@ -58,33 +58,88 @@ func main() {
 		}
 	}(db)
 
-	_, err = db.Exec("PRAGMA foreign_keys = ON")
-	if err != nil {
-		log.Fatalf("Failed to enable foreign keys: %v", err)
+	if os.Getenv("AUTO_MIGRATE") != "false" {
+		migrator, err := migrate.NewMigrator(db, cryptoManager)
+		if err != nil {
+			log.Fatalf("Failed to create migrator: %v", err)
+		}
+		if err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
 	}
 
 	connDataRepo := connection_data.NewConnectionDataRepository(db, cryptoManager)
 	tenantRepo := tenant.NewTenantRepository(db)
 	tokenRepo := token.NewRepository(db)
 	auditRepo := audit.NewAuditLogRepository(db)
+	adminRepo := admin.NewAdminRepository(db)
+	apiKeyRepo := apikey.NewAPIKeyRepository(db)
+
+	keyManager := loadKeyManager(db, cryptoManager)
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is required")
+	superAdminToken := os.Getenv("ADMIN_TOKEN")
+	if superAdminToken == "" {
+		log.Printf("Warning: ADMIN_TOKEN is not set, tenant management routes are disabled")
 	}
 
-	connDataHandler := connection_data.NewConnectionHandler(connDataRepo)
-	tokenHandler := token.NewJWTHandler(tokenRepo, connDataRepo, jwtSecret)
+	tokenHandler := token.NewJWTHandler(tokenRepo, connDataRepo, keyManager)
 	tenantHandler := tenant.NewTenantHandler(tenantRepo)
 	auditHandler := audit.NewAuditHandler(auditRepo)
+	adminHandler := admin.NewAdminHandler(adminRepo, tenantRepo, keyManager)
+	apiKeyHandler := apikey.NewAPIKeyHandler(apiKeyRepo)
+	signingHandler := signing.NewSigningHandler(keyManager)
+
+	// PUBLIC_URL enables the MCP authorization flow: protected-resource
+	// metadata and the built-in OAuth authorization server.
+	publicURL := os.Getenv("PUBLIC_URL")
+	var oauthHandler *oauth.Handler
+	if publicURL != "" {
+		oauthCfg := oauth.Config{PublicURL: publicURL}
+		if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+			oauthCfg.AuthorizationServers = []string{issuer}
+		}
+		if ttl := os.Getenv("OAUTH_TOKEN_TTL"); ttl != "" {
+			var err error
+			if oauthCfg.TokenTTL, err = time.ParseDuration(ttl); err != nil {
+				log.Fatalf("Invalid OAUTH_TOKEN_TTL: %v", err)
+			}
+		}
+		oauthHandler = oauth.NewOAuthHandler(oauth.NewOAuthRepository(db), tokenRepo, connDataRepo, apiKeyRepo, keyManager, oauthCfg)
+	}
 
-	authMiddleware := middleware.NewAuthMiddleware(jwtSecret, tokenRepo)
+	activityChecker := middleware.NewActivityChecker(tenantRepo, connDataRepo, middleware.DefaultActivityCacheTTL)
+	authMiddleware := middleware.NewAuthMiddleware(keyManager, loadOIDCVerifier(), activityChecker, tokenRepo, adminRepo, apiKeyRepo, superAdminToken, publicURL)
+
+	connManager := connection.NewConnectionManager(loadManagerConfig())
+	connDataRepo.OnChange(connManager.Invalidate)
+	connDataHandler := connection_data.NewConnectionHandler(connDataRepo, connManager.Test)
+	defer func(connManager *connection.Manager) {
+		err := connManager.CloseAll()
+		if err != nil {
+			log.Printf("Failed to close adapters: %v", err)
+		}
+	}(connManager)
+
+	healthMonitor := health.NewMonitor(connDataRepo, connManager, loadHealthConfig())
+	healthHandler := health.NewHealthHandler(healthMonitor, connDataRepo)
 
 	mcpServer := mcp.NewServer(&mcp.Implementation{
 		Title:   "Pinoql MCP Server",
 		Version: "v0.1.0",
 	}, nil)
 
+	auditWriter := audit.NewWriter(auditRepo, audit.DefaultWriterBufferSize)
+	defer func(auditWriter *audit.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := auditWriter.Close(ctx); err != nil {
+			log.Printf("Failed to flush audit logs: %v", err)
+		}
+	}(auditWriter)
+
+	toolHandler := mcptools.NewToolHandler(connDataRepo, connManager, auditWriter)
+	toolHandler.Register(mcpServer)
+
 	mcpHandler := mcp.NewStreamableHTTPHandler(
 		func(*http.Request) *mcp.Server { return mcpServer },
 		&mcp.StreamableHTTPOptions{},
@@ -101,8 +156,14 @@ func main() {
 		TokenHandler:          tokenHandler,
 		TenantHandler:         tenantHandler,
 		AuditHandler:          auditHandler,
+		AdminHandler:          adminHandler,
+		APIKeyHandler:         apiKeyHandler,
+		SigningHandler:        signingHandler,
+		HealthHandler:         healthHandler,
+		OAuthHandler:          oauthHandler,
 		AuthMiddleware:        authMiddleware,
 		MCPHandler:            mcpHandler,
+		ConnectionManager:     connManager,
 	}
 
 	routes.SetupRoutes(r, routerConfig)
@@ -111,8 +172,32 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Starting Pinoql MCP Server on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal(err)
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	keyManager.StartRotation(ctx)
+	connManager.StartJanitor(ctx)
+	healthMonitor.Start(ctx)
+
+	go func() {
+		log.Printf("Starting Pinoql MCP Server on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Server error: %v", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
 	}
 }
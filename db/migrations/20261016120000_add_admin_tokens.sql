@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE admin_tokens (
+    jti TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    description TEXT,
+    issued_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked INTEGER NOT NULL DEFAULT 0,
+    revoked_at DATETIME,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id)
+);
+
+CREATE INDEX idx_admin_tokens_tenant ON admin_tokens(tenant_id, revoked);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_admin_tokens_tenant;
+DROP TABLE IF EXISTS admin_tokens;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    secret_hash TEXT NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '[]',
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    revoked INTEGER NOT NULL DEFAULT 0,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX idx_api_keys_prefix ON api_keys(prefix);
+CREATE INDEX idx_api_keys_tenant ON api_keys(tenant_id, revoked);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_api_keys_tenant;
+DROP INDEX IF EXISTS idx_api_keys_prefix;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Rows written before this migration keep an empty key ID; they are matched
+-- against every configured master key until re-wrapped by "pinoql rotate-keys".
+ALTER TABLE connection_data ADD COLUMN dek_key_id TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE connection_data DROP COLUMN dek_key_id;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE signing_keys (
+    kid TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL,
+    private_key TEXT NOT NULL,
+    dek TEXT NOT NULL,
+    dek_key_id TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    rotated_at DATETIME
+);
+
+CREATE INDEX idx_signing_keys_rotated ON signing_keys(rotated_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_signing_keys_rotated;
+DROP TABLE IF EXISTS signing_keys;
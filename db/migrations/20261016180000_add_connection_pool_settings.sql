@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE connection_data ADD COLUMN max_idle_connections INTEGER NOT NULL DEFAULT 2;
+ALTER TABLE connection_data ADD COLUMN conn_max_idle_time_seconds INTEGER NOT NULL DEFAULT 300;
+ALTER TABLE connection_data ADD COLUMN conn_max_lifetime_seconds INTEGER NOT NULL DEFAULT 3600;
+UPDATE connection_data SET max_connections = 10 WHERE max_connections IS NULL OR max_connections < 1;
+
+-- +goose Down
+ALTER TABLE connection_data DROP COLUMN conn_max_lifetime_seconds;
+ALTER TABLE connection_data DROP COLUMN conn_max_idle_time_seconds;
+ALTER TABLE connection_data DROP COLUMN max_idle_connections;
@@ -0,0 +1,65 @@
+-- +goose Up
+-- Calls with a missing or unknown connection_id are audited too, so the
+-- column no longer references connection_data. rows_affected only ever held
+-- the number of rows a query returned and is renamed accordingly.
+CREATE TABLE connection_audit_log_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  tenant_id TEXT NOT NULL,
+  connection_id TEXT NOT NULL DEFAULT '',
+  action TEXT NOT NULL, -- 'query', 'schema', 'connect'
+  query_hash TEXT,
+  success INTEGER NOT NULL,
+  error_message TEXT,
+  execution_time_ms INTEGER,
+  rows_returned INTEGER,
+  timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (tenant_id) REFERENCES tenants(id)
+);
+
+INSERT INTO connection_audit_log_new (
+  id, tenant_id, connection_id, action, query_hash, success,
+  error_message, execution_time_ms, rows_returned, timestamp
+)
+SELECT
+  id, tenant_id, connection_id, action, query_hash, success,
+  error_message, execution_time_ms, rows_affected, timestamp
+FROM connection_audit_log;
+
+DROP TABLE connection_audit_log;
+ALTER TABLE connection_audit_log_new RENAME TO connection_audit_log;
+
+CREATE INDEX idx_audit_tenant_time ON connection_audit_log(tenant_id, timestamp);
+CREATE INDEX idx_audit_connection_time ON connection_audit_log(connection_id, timestamp);
+
+-- +goose Down
+CREATE TABLE connection_audit_log_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  tenant_id TEXT NOT NULL,
+  connection_id TEXT NOT NULL,
+  action TEXT NOT NULL, -- 'query', 'schema', 'connect'
+  query_hash TEXT,
+  success INTEGER NOT NULL,
+  error_message TEXT,
+  execution_time_ms INTEGER,
+  rows_affected INTEGER,
+  timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (tenant_id) REFERENCES tenants(id),
+  FOREIGN KEY (connection_id) REFERENCES connection_data(id)
+);
+
+-- Rows without a stored connection cannot satisfy the foreign key.
+INSERT INTO connection_audit_log_old (
+  id, tenant_id, connection_id, action, query_hash, success,
+  error_message, execution_time_ms, rows_affected, timestamp
+)
+SELECT
+  id, tenant_id, connection_id, action, query_hash, success,
+  error_message, execution_time_ms, rows_returned, timestamp
+FROM connection_audit_log
+WHERE connection_id IN (SELECT id FROM connection_data);
+
+DROP TABLE connection_audit_log;
+ALTER TABLE connection_audit_log_old RENAME TO connection_audit_log;
+
+CREATE INDEX idx_audit_tenant_time ON connection_audit_log(tenant_id, timestamp);
+CREATE INDEX idx_audit_connection_time ON connection_audit_log(connection_id, timestamp);
@@ -1,11 +1,16 @@
 package adapters
 
-import "github.com/jmoiron/sqlx"
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
 
 type Adapter interface {
-	HealthCheck() error
-	RunQuery(query string, args ...any) (*sqlx.Rows, error)
-	DescribeSchema() (string, error)
+	HealthCheck(ctx context.Context) error
+	RunQuery(ctx context.Context, query string, args ...any) (*Rows, error)
+	DescribeSchema(ctx context.Context) (*DatabaseSchema, error)
+	Probe(ctx context.Context) (*ProbeResult, error)
 	GetDB() *sqlx.DB
 	Close() error
 }
@@ -0,0 +1,92 @@
+package duckdb
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ adapters.Adapter = (*Adapter)(nil)
+
+type Adapter struct {
+	DB *sqlx.DB
+}
+
+func NewDuckDBAdapter(dsn string, readOnly bool) (*Adapter, error) {
+	dsn = sandboxedDSN(dsn)
+	if readOnly {
+		dsn = readOnlyDSN(dsn)
+	}
+
+	db, err := sqlx.Open("duckdb", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Adapter{DB: db}, nil
+}
+
+// sandboxedDSN disables access to anything but the database itself:
+// read_csv, read_text, COPY, ATTACH and extension loading could otherwise
+// read or write any file on the server, and access_mode=read_only does not
+// prevent that. Locking the configuration stops queries from re-enabling it.
+func sandboxedDSN(dsn string) string {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		params = url.Values{}
+	}
+	params.Set("enable_external_access", "false")
+	params.Set("lock_configuration", "true")
+
+	return path + "?" + params.Encode()
+}
+
+// readOnlyDSN attaches the database file with access_mode=read_only, so DuckDB
+// rejects writes and allows other processes to read the same file.
+func readOnlyDSN(dsn string) string {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		params = url.Values{}
+	}
+	params.Set("access_mode", "read_only")
+
+	return path + "?" + params.Encode()
+}
+
+func (d *Adapter) GetDB() *sqlx.DB {
+	return d.DB
+}
+
+func (d *Adapter) HealthCheck(ctx context.Context) error {
+	return d.DB.PingContext(ctx)
+}
+
+func (d *Adapter) Close() error {
+	return d.DB.Close()
+}
+
+func (d *Adapter) RunQuery(ctx context.Context, query string, args ...any) (*adapters.Rows, error) {
+	return adapters.Query(ctx, d.DB, adapters.QueryOptions{}, query, args...)
+}
+
+func (d *Adapter) Probe(ctx context.Context) (*adapters.ProbeResult, error) {
+	var result adapters.ProbeResult
+
+	if err := d.DB.GetContext(ctx, &result.ServerVersion, "SELECT version()"); err != nil {
+		return nil, err
+	}
+
+	var path *string
+	if err := d.DB.GetContext(ctx, &path, "SELECT path FROM duckdb_databases() WHERE database_name = current_database()"); err != nil {
+		return nil, err
+	}
+	result.CanWrite = path == nil || adapters.FileWritable(*path)
+
+	return &result, nil
+}
@@ -0,0 +1,194 @@
+package duckdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/jmoiron/sqlx"
+)
+
+func (d *Adapter) DescribeSchema(ctx context.Context) (*adapters.DatabaseSchema, error) {
+	builder := adapters.NewSchemaBuilder()
+
+	if err := d.loadTables(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := d.loadColumns(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := d.loadConstraints(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := d.loadIndexes(ctx, builder); err != nil {
+		return nil, err
+	}
+
+	return builder.Build(), nil
+}
+
+func (d *Adapter) loadTables(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := d.DB.QueryxContext(ctx, `
+		SELECT schema_name, table_name, 'table' AS kind, comment
+		FROM duckdb_tables()
+		WHERE NOT internal AND database_name = current_database()
+		UNION ALL
+		SELECT schema_name, view_name, 'view', comment
+		FROM duckdb_views()
+		WHERE NOT internal AND database_name = current_database()
+		ORDER BY 1, 2
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName, kind string
+		var comment *string
+		if err := rows.Scan(&schemaName, &tableName, &kind, &comment); err != nil {
+			return fmt.Errorf("failed to scan table: %w", err)
+		}
+		builder.AddTable(schemaName, tableName, adapters.TableKind(kind), comment)
+	}
+
+	return rows.Err()
+}
+
+func (d *Adapter) loadColumns(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := d.DB.QueryxContext(ctx, `
+		SELECT schema_name, table_name, column_name, data_type, is_nullable, column_default, comment
+		FROM duckdb_columns()
+		WHERE NOT internal AND database_name = current_database()
+		ORDER BY schema_name, table_name, column_index
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list columns: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName string
+		var column adapters.Column
+		if err := rows.Scan(
+			&schemaName, &tableName, &column.Name, &column.DataType,
+			&column.Nullable, &column.Default, &column.Comment,
+		); err != nil {
+			return fmt.Errorf("failed to scan column: %w", err)
+		}
+		if table := builder.Table(schemaName, tableName); table != nil {
+			table.Columns = append(table.Columns, column)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (d *Adapter) loadConstraints(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := d.DB.QueryxContext(ctx, `
+		SELECT
+			schema_name, table_name, constraint_name, constraint_type,
+			to_json(constraint_column_names)::VARCHAR,
+			COALESCE(referenced_table, ''),
+			to_json(referenced_column_names)::VARCHAR
+		FROM duckdb_constraints()
+		WHERE database_name = current_database()
+			AND constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+		ORDER BY schema_name, table_name, constraint_index
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list constraints: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName, name, kind, columnsJSON, refTable, refColumnsJSON string
+		if err := rows.Scan(
+			&schemaName, &tableName, &name, &kind, &columnsJSON, &refTable, &refColumnsJSON,
+		); err != nil {
+			return fmt.Errorf("failed to scan constraint: %w", err)
+		}
+
+		table := builder.Table(schemaName, tableName)
+		if table == nil {
+			continue
+		}
+
+		var columns, refColumns []string
+		if err := json.Unmarshal([]byte(columnsJSON), &columns); err != nil {
+			return fmt.Errorf("failed to decode constraint columns: %w", err)
+		}
+		if err := json.Unmarshal([]byte(refColumnsJSON), &refColumns); err != nil {
+			return fmt.Errorf("failed to decode referenced columns: %w", err)
+		}
+
+		switch kind {
+		case "PRIMARY KEY":
+			table.PrimaryKey = columns
+		case "UNIQUE":
+			table.UniqueConstraints = append(table.UniqueConstraints, adapters.UniqueConstraint{
+				Name:    name,
+				Columns: columns,
+			})
+		case "FOREIGN KEY":
+			table.ForeignKeys = append(table.ForeignKeys, adapters.ForeignKey{
+				Name:              name,
+				Columns:           columns,
+				ReferencedSchema:  schemaName,
+				ReferencedTable:   refTable,
+				ReferencedColumns: refColumns,
+			})
+		}
+	}
+
+	return rows.Err()
+}
+
+func (d *Adapter) loadIndexes(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := d.DB.QueryxContext(ctx, `
+		SELECT schema_name, table_name, index_name, is_unique, COALESCE(expressions, ''), COALESCE(sql, '')
+		FROM duckdb_indexes()
+		WHERE database_name = current_database()
+		ORDER BY schema_name, table_name, index_name
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName, expressions string
+		var index adapters.Index
+		if err := rows.Scan(
+			&schemaName, &tableName, &index.Name, &index.Unique, &expressions, &index.Definition,
+		); err != nil {
+			return fmt.Errorf("failed to scan index: %w", err)
+		}
+		index.Columns = parseExpressions(expressions)
+		if table := builder.Table(schemaName, tableName); table != nil {
+			table.Indexes = append(table.Indexes, index)
+		}
+	}
+
+	return rows.Err()
+}
+
+// parseExpressions splits the "[a, b]" rendering DuckDB uses for index keys.
+func parseExpressions(expressions string) []string {
+	expressions = strings.TrimSuffix(strings.TrimPrefix(expressions, "["), "]")
+	if expressions == "" {
+		return []string{}
+	}
+
+	parts := strings.Split(expressions, ", ")
+	for i, part := range parts {
+		parts[i] = strings.Trim(part, `"`)
+	}
+	return parts
+}
+
+func closeRows(rows *sqlx.Rows) {
+	_ = rows.Close()
+}
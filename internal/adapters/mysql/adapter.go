@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ adapters.Adapter = (*Adapter)(nil)
+
+// serverCancel kills statements whose context ended. go-sql-driver/mysql
+// only closes its connection on cancellation, which leaves the statement
+// running on the server until it completes.
+var serverCancel = adapters.ServerCancel{
+	BackendIDQuery: "SELECT CONNECTION_ID()",
+	CancelQuery:    "KILL QUERY %d",
+}
+
+type Adapter struct {
+	DB       *sqlx.DB
+	readOnly bool
+}
+
+func NewMySQLAdapter(dsn string, readOnly bool) (*Adapter, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAdapter(connector, readOnly), nil
+}
+
+func newAdapter(connector driver.Connector, readOnly bool) *Adapter {
+	if readOnly {
+		connector = &readOnlyConnector{Connector: connector}
+	}
+
+	return &Adapter{DB: sqlx.NewDb(sql.OpenDB(connector), "mysql"), readOnly: readOnly}
+}
+
+// readOnlyConnector marks every pooled session as read-only right after it is
+// opened, so writes are rejected by the server regardless of the SQL sent.
+type readOnlyConnector struct {
+	driver.Connector
+}
+
+func (c *readOnlyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("mysql driver connection does not support ExecContext")
+	}
+
+	if _, err := execer.ExecContext(ctx, "SET SESSION TRANSACTION READ ONLY", nil); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to set session read only: %w", err)
+	}
+
+	return conn, nil
+}
+
+func (m *Adapter) GetDB() *sqlx.DB {
+	return m.DB
+}
+
+func (m *Adapter) HealthCheck(ctx context.Context) error {
+	return m.DB.PingContext(ctx)
+}
+
+func (m *Adapter) Close() error {
+	return m.DB.Close()
+}
+
+func (m *Adapter) RunQuery(ctx context.Context, query string, args ...any) (*adapters.Rows, error) {
+	return adapters.Query(ctx, m.DB, adapters.QueryOptions{
+		ReadOnly:     m.readOnly,
+		ServerCancel: &serverCancel,
+	}, query, args...)
+}
+
+// writePrivileges are the privileges that let a user change data or schema.
+const writePrivileges = "'INSERT', 'UPDATE', 'DELETE', 'CREATE', 'DROP', 'ALTER'"
+
+// writeCheckQuery reports whether the server accepts writes and the grantee
+// holds a write privilege globally, on a schema or on a table.
+const writeCheckQuery = `
+	SELECT @@global.read_only = 0 AND (
+		EXISTS (SELECT 1 FROM information_schema.user_privileges WHERE grantee = ? AND privilege_type IN (` + writePrivileges + `))
+		OR EXISTS (SELECT 1 FROM information_schema.schema_privileges WHERE grantee = ? AND privilege_type IN (` + writePrivileges + `))
+		OR EXISTS (SELECT 1 FROM information_schema.table_privileges WHERE grantee = ? AND privilege_type IN (` + writePrivileges + `))
+	)`
+
+func (m *Adapter) Probe(ctx context.Context) (*adapters.ProbeResult, error) {
+	var result adapters.ProbeResult
+
+	err := m.DB.QueryRowxContext(ctx, "SELECT VERSION(), CURRENT_USER()").Scan(&result.ServerVersion, &result.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+
+	// CURRENT_USER() is user@host while grantees are quoted as 'user'@'host'.
+	// User names may contain "@" but host names cannot.
+	user, host := result.CurrentUser, ""
+	if i := strings.LastIndex(result.CurrentUser, "@"); i >= 0 {
+		user, host = result.CurrentUser[:i], result.CurrentUser[i+1:]
+	}
+	grantee := fmt.Sprintf("'%s'@'%s'", user, host)
+
+	if err := m.DB.GetContext(ctx, &result.CanWrite, writeCheckQuery, grantee, grantee, grantee); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
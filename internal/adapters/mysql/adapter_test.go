@@ -0,0 +1,262 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer stands in for a MySQL server: it answers the statements the
+// adapter sends and records them per session.
+type fakeServer struct {
+	mu          sync.Mutex
+	currentUser string
+	// writers are the grantees holding a write privilege.
+	writers  []string
+	sessions int64
+	log      []string
+	// block makes "SELECT SLEEP(60)" wait until its context ends.
+	block bool
+}
+
+func (s *fakeServer) record(session int64, statement string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, fmt.Sprintf("%d: %s", session, statement))
+}
+
+func (s *fakeServer) statements() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.log)
+}
+
+func (s *fakeServer) Connect(context.Context) (driver.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions++
+	return &fakeConn{server: s, id: s.sessions}, nil
+}
+
+func (s *fakeServer) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use the connector")
+}
+
+type fakeConn struct {
+	server *fakeServer
+	id     int64
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		c.server.record(c.id, "START TRANSACTION READ ONLY")
+	} else {
+		c.server.record(c.id, "START TRANSACTION")
+	}
+	return fakeTx{conn: c}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.server.record(c.id, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.server.record(c.id, query)
+
+	switch {
+	case query == "SELECT CONNECTION_ID()":
+		return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{c.id}}}, nil
+
+	case query == "SELECT VERSION(), CURRENT_USER()":
+		return &fakeRows{
+			columns: []string{"version", "user"},
+			values:  [][]driver.Value{{"8.0.36", c.server.currentUser}},
+		}, nil
+
+	case strings.Contains(query, "information_schema.user_privileges"):
+		grantee, _ := args[0].Value.(string)
+		canWrite := int64(0)
+		if slices.Contains(c.server.writers, grantee) {
+			canWrite = 1
+		}
+		return &fakeRows{columns: []string{"can_write"}, values: [][]driver.Value{{canWrite}}}, nil
+
+	case query == "SELECT SLEEP(60)":
+		if c.server.block {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &fakeRows{columns: []string{"sleep"}, values: [][]driver.Value{{int64(0)}}}, nil
+
+	case query == "SELECT 1 AS n":
+		return &fakeRows{columns: []string{"n"}, values: [][]driver.Value{{int64(1)}}}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected query %q", query)
+}
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (t fakeTx) Commit() error {
+	t.conn.server.record(t.conn.id, "COMMIT")
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.conn.server.record(t.conn.id, "ROLLBACK")
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestReadOnlyAdapter(t *testing.T) {
+	server := &fakeServer{}
+	adapter := newAdapter(server, true)
+	defer func() { _ = adapter.Close() }()
+
+	rows, err := adapter.RunQuery(context.Background(), "SELECT 1 AS n")
+	if err != nil {
+		t.Fatalf("RunQuery: %v", err)
+	}
+
+	var n []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		n = append(n, v)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !slices.Equal(n, []int64{1}) {
+		t.Fatalf("rows = %v, want [1]", n)
+	}
+
+	want := []string{
+		"1: SET SESSION TRANSACTION READ ONLY",
+		"1: SELECT CONNECTION_ID()",
+		"1: START TRANSACTION READ ONLY",
+		"1: SELECT 1 AS n",
+		"1: ROLLBACK",
+	}
+	if got := server.statements(); !slices.Equal(got, want) {
+		t.Fatalf("statements =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWritableAdapterSkipsReadOnlySession(t *testing.T) {
+	server := &fakeServer{}
+	adapter := newAdapter(server, false)
+	defer func() { _ = adapter.Close() }()
+
+	rows, err := adapter.RunQuery(context.Background(), "SELECT 1 AS n")
+	if err != nil {
+		t.Fatalf("RunQuery: %v", err)
+	}
+	_ = rows.Close()
+
+	for _, statement := range server.statements() {
+		if strings.Contains(statement, "READ ONLY") {
+			t.Fatalf("writable adapter ran %q", statement)
+		}
+	}
+}
+
+func TestQueryCancelledOnServer(t *testing.T) {
+	server := &fakeServer{block: true}
+	adapter := newAdapter(server, true)
+	defer func() { _ = adapter.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := adapter.RunQuery(ctx, "SELECT SLEEP(60)"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunQuery error = %v, want deadline exceeded", err)
+	}
+
+	if !slices.Contains(server.statements(), "2: KILL QUERY 1") {
+		t.Fatalf("statements = %v, want KILL QUERY 1 from another session", server.statements())
+	}
+}
+
+func TestProbeGrantee(t *testing.T) {
+	tests := []struct {
+		name        string
+		currentUser string
+		writers     []string
+		canWrite    bool
+	}{
+		{"read-only user", "reader@%", []string{"'admin'@'%'"}, false},
+		{"writer", "app@localhost", []string{"'app'@'localhost'"}, true},
+		{"user name containing @", "ci@example.com@10.0.0.%", []string{"'ci@example.com'@'10.0.0.%'"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fakeServer{currentUser: tt.currentUser, writers: tt.writers}
+			adapter := newAdapter(server, true)
+			defer func() { _ = adapter.Close() }()
+
+			result, err := adapter.Probe(context.Background())
+			if err != nil {
+				t.Fatalf("Probe: %v", err)
+			}
+			if result.ServerVersion != "8.0.36" || result.CurrentUser != tt.currentUser {
+				t.Fatalf("Probe = %+v", result)
+			}
+			if result.CanWrite != tt.canWrite {
+				t.Fatalf("CanWrite = %v, want %v", result.CanWrite, tt.canWrite)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/jmoiron/sqlx"
+)
+
+const userSchemasFilter = `NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')`
+
+func (m *Adapter) DescribeSchema(ctx context.Context) (*adapters.DatabaseSchema, error) {
+	builder := adapters.NewSchemaBuilder()
+
+	if err := m.loadTables(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := m.loadColumns(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := m.loadConstraints(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := m.loadIndexes(ctx, builder); err != nil {
+		return nil, err
+	}
+
+	return builder.Build(), nil
+}
+
+func (m *Adapter) loadTables(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := m.DB.QueryxContext(ctx, `
+		SELECT TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE, TABLE_COMMENT
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA `+userSchemasFilter+`
+		ORDER BY TABLE_SCHEMA, TABLE_NAME
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName, tableType string
+		var comment *string
+		if err := rows.Scan(&schemaName, &tableName, &tableType, &comment); err != nil {
+			return fmt.Errorf("failed to scan table: %w", err)
+		}
+
+		kind := adapters.KindTable
+		if tableType == "VIEW" {
+			kind = adapters.KindView
+		}
+		if comment != nil && (*comment == "" || kind == adapters.KindView) {
+			comment = nil
+		}
+		builder.AddTable(schemaName, tableName, kind, comment)
+	}
+
+	return rows.Err()
+}
+
+func (m *Adapter) loadColumns(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := m.DB.QueryxContext(ctx, `
+		SELECT
+			TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, COLUMN_TYPE,
+			IS_NULLABLE = 'YES', COLUMN_DEFAULT, COLUMN_COMMENT
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA `+userSchemasFilter+`
+		ORDER BY TABLE_SCHEMA, TABLE_NAME, ORDINAL_POSITION
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list columns: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName string
+		var column adapters.Column
+		if err := rows.Scan(
+			&schemaName, &tableName, &column.Name, &column.DataType,
+			&column.Nullable, &column.Default, &column.Comment,
+		); err != nil {
+			return fmt.Errorf("failed to scan column: %w", err)
+		}
+		if column.Comment != nil && *column.Comment == "" {
+			column.Comment = nil
+		}
+		if table := builder.Table(schemaName, tableName); table != nil {
+			table.Columns = append(table.Columns, column)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (m *Adapter) loadConstraints(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := m.DB.QueryxContext(ctx, `
+		SELECT
+			tc.TABLE_SCHEMA, tc.TABLE_NAME, tc.CONSTRAINT_NAME, tc.CONSTRAINT_TYPE,
+			kcu.COLUMN_NAME,
+			COALESCE(kcu.REFERENCED_TABLE_SCHEMA, ''),
+			COALESCE(kcu.REFERENCED_TABLE_NAME, ''),
+			COALESCE(kcu.REFERENCED_COLUMN_NAME, ''),
+			COALESCE(rc.UPDATE_RULE, ''),
+			COALESCE(rc.DELETE_RULE, '')
+		FROM information_schema.TABLE_CONSTRAINTS tc
+		JOIN information_schema.KEY_COLUMN_USAGE kcu
+			ON kcu.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+			AND kcu.TABLE_NAME = tc.TABLE_NAME
+			AND kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+		LEFT JOIN information_schema.REFERENTIAL_CONSTRAINTS rc
+			ON rc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+			AND rc.TABLE_NAME = tc.TABLE_NAME
+			AND rc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+		WHERE tc.CONSTRAINT_TYPE IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+			AND tc.TABLE_SCHEMA `+userSchemasFilter+`
+		ORDER BY tc.TABLE_SCHEMA, tc.TABLE_NAME, tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list constraints: %w", err)
+	}
+	defer closeRows(rows)
+
+	var lastKey string
+	for rows.Next() {
+		var schemaName, tableName, name, kind, column string
+		var refSchema, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(
+			&schemaName, &tableName, &name, &kind, &column,
+			&refSchema, &refTable, &refColumn, &onUpdate, &onDelete,
+		); err != nil {
+			return fmt.Errorf("failed to scan constraint: %w", err)
+		}
+
+		table := builder.Table(schemaName, tableName)
+		if table == nil {
+			continue
+		}
+
+		key := schemaName + "." + tableName + "." + name
+		isNew := key != lastKey
+		lastKey = key
+
+		switch kind {
+		case "PRIMARY KEY":
+			table.PrimaryKey = append(table.PrimaryKey, column)
+		case "UNIQUE":
+			if isNew {
+				table.UniqueConstraints = append(table.UniqueConstraints, adapters.UniqueConstraint{Name: name})
+			}
+			unique := &table.UniqueConstraints[len(table.UniqueConstraints)-1]
+			unique.Columns = append(unique.Columns, column)
+		case "FOREIGN KEY":
+			if isNew {
+				table.ForeignKeys = append(table.ForeignKeys, adapters.ForeignKey{
+					Name:             name,
+					ReferencedSchema: refSchema,
+					ReferencedTable:  refTable,
+					OnUpdate:         onUpdate,
+					OnDelete:         onDelete,
+				})
+			}
+			fk := &table.ForeignKeys[len(table.ForeignKeys)-1]
+			fk.Columns = append(fk.Columns, column)
+			fk.ReferencedColumns = append(fk.ReferencedColumns, refColumn)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (m *Adapter) loadIndexes(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := m.DB.QueryxContext(ctx, `
+		SELECT TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, NON_UNIQUE = 0, COALESCE(COLUMN_NAME, ''), INDEX_TYPE
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA `+userSchemasFilter+`
+		ORDER BY TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer closeRows(rows)
+
+	var lastKey string
+	for rows.Next() {
+		var schemaName, tableName, name, column, indexType string
+		var unique bool
+		if err := rows.Scan(&schemaName, &tableName, &name, &unique, &column, &indexType); err != nil {
+			return fmt.Errorf("failed to scan index: %w", err)
+		}
+
+		table := builder.Table(schemaName, tableName)
+		if table == nil {
+			continue
+		}
+
+		key := schemaName + "." + tableName + "." + name
+		if key != lastKey {
+			table.Indexes = append(table.Indexes, adapters.Index{
+				Name:       name,
+				Columns:    []string{},
+				Unique:     unique,
+				Definition: indexType,
+			})
+		}
+		lastKey = key
+
+		if column != "" {
+			index := &table.Indexes[len(table.Indexes)-1]
+			index.Columns = append(index.Columns, column)
+		}
+	}
+
+	return rows.Err()
+}
+
+func closeRows(rows *sqlx.Rows) {
+	_ = rows.Close()
+}
@@ -1,61 +1,79 @@
 package postgres
 
-import "github.com/jmoiron/sqlx"
+import (
+	"context"
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+var _ adapters.Adapter = (*Adapter)(nil)
 
 type Adapter struct {
-	DB *sqlx.DB
+	DB       *sqlx.DB
+	readOnly bool
 }
 
-func NewPostgresAdapter(dsn string) (*Adapter, error) {
+func NewPostgresAdapter(dsn string, readOnly bool) (*Adapter, error) {
 	db, err := sqlx.Open("postgres", dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Adapter{DB: db}, nil
+	return &Adapter{DB: db, readOnly: readOnly}, nil
 }
 
 func (p *Adapter) GetDB() *sqlx.DB {
 	return p.DB
 }
 
-func (p *Adapter) HealthCheck() error {
-	return p.DB.Ping()
+func (p *Adapter) HealthCheck(ctx context.Context) error {
+	return p.DB.PingContext(ctx)
 }
 
 func (p *Adapter) Close() error {
 	return p.DB.Close()
 }
 
-func (p *Adapter) RunQuery(query string, args ...any) (*sqlx.Rows, error) {
-	return p.DB.Queryx(query, args...)
+// RunQuery needs no ServerCancel: lib/pq sends a cancel request to the
+// server itself when ctx ends during a query.
+func (p *Adapter) RunQuery(ctx context.Context, query string, args ...any) (*adapters.Rows, error) {
+	return adapters.Query(ctx, p.DB, adapters.QueryOptions{
+		ReadOnly: p.readOnly,
+	}, query, args...)
 }
 
-func (p *Adapter) DescribeSchema() (string, error) {
-	rows, err := p.DB.Queryx(
-		` SELECT table_name, column_name, data_type 
- 				FROM information_schema.columns 
- 				WHERE table_schema = 'public' 
- 				ORDER BY table_name, ordinal_position`)
-	if err != nil {
-		return "", err
-	}
-
-	defer func(rows *sqlx.Rows) {
-		err := rows.Close()
-		if err != nil {
+// writeCheckQuery reports whether the current user could write: the server
+// must not be a standby and the user must be a superuser, hold a write
+// privilege on some user table or be allowed to create objects in some user
+// schema.
+const writeCheckQuery = `
+	SELECT NOT pg_is_in_recovery() AND (
+		(SELECT rolsuper FROM pg_roles WHERE rolname = current_user)
+		OR EXISTS (
+			SELECT 1 FROM pg_tables
+			WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+				AND has_table_privilege(quote_ident(schemaname) || '.' || quote_ident(tablename), 'INSERT, UPDATE, DELETE, TRUNCATE')
+		)
+		OR EXISTS (
+			SELECT 1 FROM pg_namespace
+			WHERE nspname NOT IN ('pg_catalog', 'information_schema')
+				AND nspname NOT LIKE 'pg\_%'
+				AND has_schema_privilege(oid, 'CREATE')
+		)
+	)`
 
-		}
-	}(rows)
+func (p *Adapter) Probe(ctx context.Context) (*adapters.ProbeResult, error) {
+	var result adapters.ProbeResult
 
-	schema := ""
+	err := p.DB.QueryRowxContext(ctx, "SELECT current_setting('server_version'), current_user").Scan(&result.ServerVersion, &result.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
 
-	for rows.Next() {
-		var table, column, dtype string
-		if err := rows.Scan(&table, &column, &dtype); err != nil {
-			return "", err
-		}
+	if err := p.DB.GetContext(ctx, &result.CanWrite, writeCheckQuery); err != nil {
+		return nil, err
 	}
 
-	return schema, nil
+	return &result, nil
 }
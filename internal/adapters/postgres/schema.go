@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const userSchemasFilter = `
+	n.nspname NOT IN ('pg_catalog', 'information_schema')
+	AND n.nspname NOT LIKE 'pg_toast%'
+	AND n.nspname NOT LIKE 'pg_temp_%'
+`
+
+var tableKinds = map[string]adapters.TableKind{
+	"r": adapters.KindTable,
+	"p": adapters.KindTable,
+	"f": adapters.KindForeignTable,
+	"v": adapters.KindView,
+	"m": adapters.KindMaterializedView,
+}
+
+var referentialActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+func (p *Adapter) DescribeSchema(ctx context.Context) (*adapters.DatabaseSchema, error) {
+	builder := adapters.NewSchemaBuilder()
+
+	if err := p.loadTables(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := p.loadColumns(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := p.loadConstraints(ctx, builder); err != nil {
+		return nil, err
+	}
+	if err := p.loadIndexes(ctx, builder); err != nil {
+		return nil, err
+	}
+
+	return builder.Build(), nil
+}
+
+func (p *Adapter) loadTables(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := p.DB.QueryxContext(ctx, `
+		SELECT n.nspname, c.relname, c.relkind, obj_description(c.oid, 'pg_class')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'f', 'v', 'm') AND `+userSchemasFilter+`
+		ORDER BY n.nspname, c.relname
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName, kind string
+		var comment *string
+		if err := rows.Scan(&schemaName, &tableName, &kind, &comment); err != nil {
+			return fmt.Errorf("failed to scan table: %w", err)
+		}
+		builder.AddTable(schemaName, tableName, tableKinds[kind], comment)
+	}
+
+	return rows.Err()
+}
+
+func (p *Adapter) loadColumns(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := p.DB.QueryxContext(ctx, `
+		SELECT
+			n.nspname, c.relname, a.attname,
+			format_type(a.atttypid, a.atttypmod),
+			NOT a.attnotnull,
+			pg_get_expr(d.adbin, d.adrelid),
+			col_description(c.oid, a.attnum)
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attnum > 0 AND NOT a.attisdropped
+			AND c.relkind IN ('r', 'p', 'f', 'v', 'm') AND `+userSchemasFilter+`
+		ORDER BY n.nspname, c.relname, a.attnum
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list columns: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName string
+		var column adapters.Column
+		if err := rows.Scan(
+			&schemaName, &tableName, &column.Name, &column.DataType,
+			&column.Nullable, &column.Default, &column.Comment,
+		); err != nil {
+			return fmt.Errorf("failed to scan column: %w", err)
+		}
+		if table := builder.Table(schemaName, tableName); table != nil {
+			table.Columns = append(table.Columns, column)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (p *Adapter) loadConstraints(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := p.DB.QueryxContext(ctx, `
+		SELECT
+			n.nspname, c.relname, con.conname, con.contype,
+			ARRAY(
+				SELECT a.attname
+				FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+				ORDER BY k.ord
+			)::text[],
+			COALESCE(rn.nspname, ''), COALESCE(rc.relname, ''),
+			ARRAY(
+				SELECT a.attname
+				FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+				ORDER BY k.ord
+			)::text[],
+			con.confupdtype, con.confdeltype
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_class rc ON rc.oid = con.confrelid
+		LEFT JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+		WHERE con.contype IN ('p', 'u', 'f') AND `+userSchemasFilter+`
+		ORDER BY n.nspname, c.relname, con.conname
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list constraints: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName, name, kind, refSchema, refTable, onUpdate, onDelete string
+		var columns, refColumns pq.StringArray
+		if err := rows.Scan(
+			&schemaName, &tableName, &name, &kind, &columns,
+			&refSchema, &refTable, &refColumns, &onUpdate, &onDelete,
+		); err != nil {
+			return fmt.Errorf("failed to scan constraint: %w", err)
+		}
+
+		table := builder.Table(schemaName, tableName)
+		if table == nil {
+			continue
+		}
+
+		switch kind {
+		case "p":
+			table.PrimaryKey = columns
+		case "u":
+			table.UniqueConstraints = append(table.UniqueConstraints, adapters.UniqueConstraint{
+				Name:    name,
+				Columns: columns,
+			})
+		case "f":
+			table.ForeignKeys = append(table.ForeignKeys, adapters.ForeignKey{
+				Name:              name,
+				Columns:           columns,
+				ReferencedSchema:  refSchema,
+				ReferencedTable:   refTable,
+				ReferencedColumns: refColumns,
+				OnUpdate:          referentialActions[onUpdate],
+				OnDelete:          referentialActions[onDelete],
+			})
+		}
+	}
+
+	return rows.Err()
+}
+
+func (p *Adapter) loadIndexes(ctx context.Context, builder *adapters.SchemaBuilder) error {
+	rows, err := p.DB.QueryxContext(ctx, `
+		SELECT
+			n.nspname, t.relname, i.relname, ix.indisunique,
+			pg_get_indexdef(ix.indexrelid),
+			ARRAY(
+				SELECT a.attname
+				FROM unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum
+				ORDER BY k.ord
+			)::text[]
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE `+userSchemasFilter+`
+		ORDER BY n.nspname, t.relname, i.relname
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var schemaName, tableName string
+		var index adapters.Index
+		var columns pq.StringArray
+		if err := rows.Scan(
+			&schemaName, &tableName, &index.Name, &index.Unique, &index.Definition, &columns,
+		); err != nil {
+			return fmt.Errorf("failed to scan index: %w", err)
+		}
+		index.Columns = columns
+		if table := builder.Table(schemaName, tableName); table != nil {
+			table.Indexes = append(table.Indexes, index)
+		}
+	}
+
+	return rows.Err()
+}
+
+func closeRows(rows *sqlx.Rows) {
+	_ = rows.Close()
+}
@@ -0,0 +1,30 @@
+package adapters
+
+import "os"
+
+// ProbeResult describes what a connection's credentials can do. It is
+// gathered with read-only statements only.
+type ProbeResult struct {
+	ServerVersion string `json:"server_version"`
+	CurrentUser   string `json:"current_user,omitempty"`
+	// CanWrite reports whether the database user could modify data if
+	// PinoQL did not enforce read-only access.
+	CanWrite bool `json:"can_write"`
+}
+
+// FileWritable reports whether the database file at path can be opened for
+// writing. It opens the file without creating or truncating it. An empty
+// path means an in-memory database, which is always writable.
+func FileWritable(path string) bool {
+	if path == "" {
+		return true
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return false
+	}
+	_ = f.Close()
+
+	return true
+}
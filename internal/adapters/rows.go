@@ -0,0 +1,133 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// cancelTimeout bounds how long we wait for the server to acknowledge a
+// cancellation request.
+const cancelTimeout = 5 * time.Second
+
+// Rows wraps the result of Adapter.RunQuery. Close must always be called: it
+// rolls back the read-only transaction, if any, releases the dedicated
+// connection and stops the cancellation watcher.
+type Rows struct {
+	*sqlx.Rows
+	release func()
+}
+
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	if r.release != nil {
+		r.release()
+	}
+	return err
+}
+
+// ServerCancel describes how to interrupt a statement running on a database
+// server: BackendIDQuery returns the id of the current session and
+// CancelQuery is a format string taking that id (%d) which, run from another
+// session, interrupts the statement it is executing.
+type ServerCancel struct {
+	BackendIDQuery string
+	CancelQuery    string
+}
+
+type QueryOptions struct {
+	// ReadOnly runs the query inside a read-only transaction that is always
+	// rolled back, so the database itself rejects writes.
+	ReadOnly bool
+	// ServerCancel, when set, cancels the statement on the server if ctx
+	// ends before the rows are closed. It costs a round trip per query, so
+	// drivers that already cancel on the server, like lib/pq, and embedded
+	// engines leave it nil.
+	ServerCancel *ServerCancel
+}
+
+type queryer interface {
+	QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error)
+}
+
+// Query runs query on a dedicated connection according to opts.
+func Query(ctx context.Context, db *sqlx.DB, opts QueryOptions, query string, args ...any) (*Rows, error) {
+	if !opts.ReadOnly && opts.ServerCancel == nil {
+		rows, err := db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		return &Rows{Rows: rows}, nil
+	}
+
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stopWatch := func() {}
+	if opts.ServerCancel != nil {
+		stopWatch, err = watchCancel(ctx, db, conn, opts.ServerCancel)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	var target queryer = conn
+	var tx *sqlx.Tx
+	if opts.ReadOnly {
+		tx, err = conn.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			stopWatch()
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to begin read-only transaction: %w", err)
+		}
+		target = tx
+	}
+
+	release := func() {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+		stopWatch()
+		_ = conn.Close()
+	}
+
+	rows, err := target.QueryxContext(ctx, query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	return &Rows{Rows: rows, release: release}, nil
+}
+
+// watchCancel records the backend id of conn and arranges for its running
+// statement to be cancelled from a separate connection once ctx ends. The
+// returned stop function must be called before conn goes back to the pool.
+func watchCancel(ctx context.Context, db *sqlx.DB, conn *sqlx.Conn, sc *ServerCancel) (func(), error) {
+	var backendID int64
+	if err := conn.GetContext(ctx, &backendID, sc.BackendIDQuery); err != nil {
+		return nil, fmt.Errorf("failed to get backend id: %w", err)
+	}
+
+	cancelled := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(cancelled)
+		cancelCtx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+		defer cancel()
+		_, _ = db.ExecContext(cancelCtx, fmt.Sprintf(sc.CancelQuery, backendID))
+	})
+
+	return func() {
+		// If the watcher already fired, wait for it before the connection is
+		// reused, or the cancel could hit the next query.
+		if !stop() {
+			<-cancelled
+		}
+	}, nil
+}
@@ -0,0 +1,108 @@
+package adapters
+
+type TableKind string
+
+const (
+	KindTable            TableKind = "table"
+	KindView             TableKind = "view"
+	KindMaterializedView TableKind = "materialized_view"
+	KindForeignTable     TableKind = "foreign_table"
+)
+
+type DatabaseSchema struct {
+	Schemas []*Schema `json:"schemas"`
+}
+
+type Schema struct {
+	Name   string   `json:"name"`
+	Tables []*Table `json:"tables"`
+	Views  []*Table `json:"views"`
+}
+
+type Table struct {
+	Name              string             `json:"name"`
+	Kind              TableKind          `json:"kind"`
+	Comment           *string            `json:"comment,omitempty"`
+	Columns           []Column           `json:"columns"`
+	PrimaryKey        []string           `json:"primary_key,omitempty"`
+	ForeignKeys       []ForeignKey       `json:"foreign_keys,omitempty"`
+	UniqueConstraints []UniqueConstraint `json:"unique_constraints,omitempty"`
+	Indexes           []Index            `json:"indexes,omitempty"`
+}
+
+type Column struct {
+	Name     string  `json:"name"`
+	DataType string  `json:"data_type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default,omitempty"`
+	Comment  *string `json:"comment,omitempty"`
+}
+
+type ForeignKey struct {
+	Name              string   `json:"name,omitempty"`
+	Columns           []string `json:"columns"`
+	ReferencedSchema  string   `json:"referenced_schema,omitempty"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+	OnUpdate          string   `json:"on_update,omitempty"`
+	OnDelete          string   `json:"on_delete,omitempty"`
+}
+
+type UniqueConstraint struct {
+	Name    string   `json:"name,omitempty"`
+	Columns []string `json:"columns"`
+}
+
+type Index struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	Unique     bool     `json:"unique"`
+	Definition string   `json:"definition,omitempty"`
+}
+
+// SchemaBuilder assembles a DatabaseSchema from the flat rows returned by
+// catalog queries, preserving the order in which schemas and tables are added.
+type SchemaBuilder struct {
+	schemas []*Schema
+	byName  map[string]*Schema
+	tables  map[string]*Table
+}
+
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{
+		byName: make(map[string]*Schema),
+		tables: make(map[string]*Table),
+	}
+}
+
+func (b *SchemaBuilder) AddTable(schemaName, tableName string, kind TableKind, comment *string) *Table {
+	schema, ok := b.byName[schemaName]
+	if !ok {
+		schema = &Schema{Name: schemaName, Tables: []*Table{}, Views: []*Table{}}
+		b.byName[schemaName] = schema
+		b.schemas = append(b.schemas, schema)
+	}
+
+	table := &Table{Name: tableName, Kind: kind, Comment: comment, Columns: []Column{}}
+	if kind == KindTable || kind == KindForeignTable {
+		schema.Tables = append(schema.Tables, table)
+	} else {
+		schema.Views = append(schema.Views, table)
+	}
+
+	b.tables[schemaName+"."+tableName] = table
+	return table
+}
+
+// Table returns a previously added table, or nil when it is unknown (e.g. it
+// belongs to a schema that was filtered out).
+func (b *SchemaBuilder) Table(schemaName, tableName string) *Table {
+	return b.tables[schemaName+"."+tableName]
+}
+
+func (b *SchemaBuilder) Build() *DatabaseSchema {
+	if b.schemas == nil {
+		return &DatabaseSchema{Schemas: []*Schema{}}
+	}
+	return &DatabaseSchema{Schemas: b.schemas}
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+var _ adapters.Adapter = (*Adapter)(nil)
+
+// driverName is go-sqlite3 with ATTACH disabled, since attaching would let a
+// query open any file on the server regardless of the connection's DSN.
+const driverName = "sqlite3_pinoql"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			conn.SetLimit(sqlite3.SQLITE_LIMIT_ATTACHED, 0)
+			return nil
+		},
+	})
+	sqlx.BindDriver(driverName, sqlx.QUESTION)
+}
+
+type Adapter struct {
+	DB *sqlx.DB
+}
+
+func NewSQLiteAdapter(dsn string, readOnly bool) (*Adapter, error) {
+	if readOnly {
+		dsn = readOnlyDSN(dsn)
+	}
+
+	db, err := sqlx.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Adapter{DB: db}, nil
+}
+
+// readOnlyDSN rewrites a plain path or file: URI so the database is opened
+// with mode=ro, letting SQLite itself reject any write.
+func readOnlyDSN(dsn string) string {
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		params = url.Values{}
+	}
+	params.Set("mode", "ro")
+
+	return path + "?" + params.Encode()
+}
+
+func (s *Adapter) GetDB() *sqlx.DB {
+	return s.DB
+}
+
+func (s *Adapter) HealthCheck(ctx context.Context) error {
+	return s.DB.PingContext(ctx)
+}
+
+func (s *Adapter) Close() error {
+	return s.DB.Close()
+}
+
+func (s *Adapter) RunQuery(ctx context.Context, query string, args ...any) (*adapters.Rows, error) {
+	return adapters.Query(ctx, s.DB, adapters.QueryOptions{}, query, args...)
+}
+
+func (s *Adapter) Probe(ctx context.Context) (*adapters.ProbeResult, error) {
+	var result adapters.ProbeResult
+
+	if err := s.DB.GetContext(ctx, &result.ServerVersion, "SELECT sqlite_version()"); err != nil {
+		return nil, err
+	}
+
+	var path string
+	if err := s.DB.GetContext(ctx, &path, "SELECT file FROM pragma_database_list WHERE name = 'main'"); err != nil {
+		return nil, err
+	}
+	result.CanWrite = adapters.FileWritable(path)
+
+	return &result, nil
+}
@@ -0,0 +1,213 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/jmoiron/sqlx"
+)
+
+const mainSchema = "main"
+
+type tableInfo struct {
+	name string
+	kind string
+}
+
+func (s *Adapter) DescribeSchema(ctx context.Context) (*adapters.DatabaseSchema, error) {
+	builder := adapters.NewSchemaBuilder()
+
+	tables, err := s.listTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range tables {
+		kind := adapters.KindTable
+		if info.kind == "view" {
+			kind = adapters.KindView
+		}
+		table := builder.AddTable(mainSchema, info.name, kind, nil)
+
+		if err := s.loadColumns(ctx, table); err != nil {
+			return nil, err
+		}
+		if kind != adapters.KindTable {
+			continue
+		}
+		if err := s.loadForeignKeys(ctx, table); err != nil {
+			return nil, err
+		}
+		if err := s.loadIndexes(ctx, table); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Build(), nil
+}
+
+func (s *Adapter) listTables(ctx context.Context) ([]tableInfo, error) {
+	rows, err := s.DB.QueryxContext(ctx, `
+		SELECT name, type
+		FROM sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer closeRows(rows)
+
+	var tables []tableInfo
+	for rows.Next() {
+		var info tableInfo
+		if err := rows.Scan(&info.name, &info.kind); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tables = append(tables, info)
+	}
+
+	return tables, rows.Err()
+}
+
+func (s *Adapter) loadColumns(ctx context.Context, table *adapters.Table) error {
+	rows, err := s.DB.QueryxContext(ctx, `
+		SELECT name, type, "notnull", dflt_value, pk
+		FROM pragma_table_info(?)
+		ORDER BY cid
+	`, table.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list columns of %s: %w", table.Name, err)
+	}
+	defer closeRows(rows)
+
+	type pkColumn struct {
+		name     string
+		position int
+	}
+	var pk []pkColumn
+
+	for rows.Next() {
+		var column adapters.Column
+		var notNull bool
+		var pkPosition int
+		if err := rows.Scan(&column.Name, &column.DataType, &notNull, &column.Default, &pkPosition); err != nil {
+			return fmt.Errorf("failed to scan column: %w", err)
+		}
+		column.Nullable = !notNull && pkPosition == 0
+		table.Columns = append(table.Columns, column)
+
+		if pkPosition > 0 {
+			pk = append(pk, pkColumn{name: column.Name, position: pkPosition})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sort.Slice(pk, func(i, j int) bool { return pk[i].position < pk[j].position })
+	for _, column := range pk {
+		table.PrimaryKey = append(table.PrimaryKey, column.name)
+	}
+
+	return nil
+}
+
+func (s *Adapter) loadForeignKeys(ctx context.Context, table *adapters.Table) error {
+	rows, err := s.DB.QueryxContext(ctx, `
+		SELECT id, "table", "from", "to", on_update, on_delete
+		FROM pragma_foreign_key_list(?)
+		ORDER BY id, seq
+	`, table.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list foreign keys of %s: %w", table.Name, err)
+	}
+	defer closeRows(rows)
+
+	byID := map[int]int{}
+	for rows.Next() {
+		var id int
+		var refTable, from, onUpdate, onDelete string
+		var to *string
+		if err := rows.Scan(&id, &refTable, &from, &to, &onUpdate, &onDelete); err != nil {
+			return fmt.Errorf("failed to scan foreign key: %w", err)
+		}
+
+		idx, ok := byID[id]
+		if !ok {
+			table.ForeignKeys = append(table.ForeignKeys, adapters.ForeignKey{
+				ReferencedSchema: mainSchema,
+				ReferencedTable:  refTable,
+				OnUpdate:         onUpdate,
+				OnDelete:         onDelete,
+			})
+			idx = len(table.ForeignKeys) - 1
+			byID[id] = idx
+		}
+
+		fk := &table.ForeignKeys[idx]
+		fk.Columns = append(fk.Columns, from)
+		if to != nil {
+			fk.ReferencedColumns = append(fk.ReferencedColumns, *to)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *Adapter) loadIndexes(ctx context.Context, table *adapters.Table) error {
+	type indexInfo struct {
+		Name   string  `db:"name"`
+		Unique bool    `db:"unique"`
+		Origin string  `db:"origin"`
+		SQL    *string `db:"sql"`
+	}
+
+	var indexes []indexInfo
+	err := s.DB.SelectContext(ctx, &indexes, `
+		SELECT il.name, il."unique", il.origin, m.sql
+		FROM pragma_index_list(?) il
+		LEFT JOIN sqlite_master m ON m.type = 'index' AND m.name = il.name
+		ORDER BY il.name
+	`, table.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes of %s: %w", table.Name, err)
+	}
+
+	for _, info := range indexes {
+		var columns []string
+		err := s.DB.SelectContext(ctx, &columns, `
+			SELECT COALESCE(name, '')
+			FROM pragma_index_info(?)
+			ORDER BY seqno
+		`, info.Name)
+		if err != nil {
+			return fmt.Errorf("failed to list columns of index %s: %w", info.Name, err)
+		}
+
+		if info.Origin == "u" {
+			table.UniqueConstraints = append(table.UniqueConstraints, adapters.UniqueConstraint{
+				Name:    info.Name,
+				Columns: columns,
+			})
+		}
+
+		index := adapters.Index{
+			Name:    info.Name,
+			Columns: columns,
+			Unique:  info.Unique,
+		}
+		if info.SQL != nil {
+			index.Definition = *info.SQL
+		}
+		table.Indexes = append(table.Indexes, index)
+	}
+
+	return nil
+}
+
+func closeRows(rows *sqlx.Rows) {
+	_ = rows.Close()
+}
@@ -0,0 +1,478 @@
+package classifier
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+)
+
+type Operation string
+
+const (
+	OpSelect     Operation = "SELECT"
+	OpSelectInto Operation = "SELECT INTO"
+	OpInsert     Operation = "INSERT"
+	OpUpdate     Operation = "UPDATE"
+	OpDelete     Operation = "DELETE"
+	OpMerge      Operation = "MERGE"
+	OpReplace    Operation = "REPLACE"
+	OpCopy       Operation = "COPY"
+	OpLoad       Operation = "LOAD"
+	OpCreate     Operation = "CREATE"
+	OpAlter      Operation = "ALTER"
+	OpDrop       Operation = "DROP"
+	OpTruncate   Operation = "TRUNCATE"
+	OpRename     Operation = "RENAME"
+	OpComment    Operation = "COMMENT"
+	OpGrant      Operation = "GRANT"
+	OpRevoke     Operation = "REVOKE"
+	OpCall       Operation = "CALL"
+	OpExecute    Operation = "EXECUTE"
+	OpDo         Operation = "DO"
+	OpShow       Operation = "SHOW"
+	OpDescribe   Operation = "DESCRIBE"
+	OpPragma     Operation = "PRAGMA"
+)
+
+type Category string
+
+const (
+	// CategoryRead statements only read data.
+	CategoryRead Category = "read"
+	// CategoryWrite statements modify rows (or files on the server).
+	CategoryWrite Category = "write"
+	// CategoryDDL statements change the database structure or privileges.
+	CategoryDDL Category = "ddl"
+	// CategoryAdmin covers session, transaction and maintenance commands and
+	// anything whose side effects cannot be determined from the SQL alone.
+	CategoryAdmin Category = "admin"
+)
+
+// Statement is a single classified statement of a batch. Operations lists
+// every operation the statement performs, including data-modifying CTEs, so
+// callers must authorize all of them; Operation and Category describe the
+// most privileged one.
+type Statement struct {
+	SQL        string      `json:"sql"`
+	Operation  Operation   `json:"operation"`
+	Category   Category    `json:"category"`
+	Operations []Operation `json:"operations"`
+}
+
+var categoryRank = map[Category]int{
+	CategoryRead:  0,
+	CategoryWrite: 1,
+	CategoryDDL:   2,
+	CategoryAdmin: 3,
+}
+
+var simpleStatements = map[string]struct {
+	op       Operation
+	category Category
+}{
+	"INSERT":    {OpInsert, CategoryWrite},
+	"UPDATE":    {OpUpdate, CategoryWrite},
+	"DELETE":    {OpDelete, CategoryWrite},
+	"MERGE":     {OpMerge, CategoryWrite},
+	"UPSERT":    {OpInsert, CategoryWrite},
+	"REPLACE":   {OpReplace, CategoryWrite},
+	"CREATE":    {OpCreate, CategoryDDL},
+	"ALTER":     {OpAlter, CategoryDDL},
+	"DROP":      {OpDrop, CategoryDDL},
+	"TRUNCATE":  {OpTruncate, CategoryDDL},
+	"RENAME":    {OpRename, CategoryDDL},
+	"COMMENT":   {OpComment, CategoryDDL},
+	"GRANT":     {OpGrant, CategoryDDL},
+	"REVOKE":    {OpRevoke, CategoryDDL},
+	"CALL":      {OpCall, CategoryAdmin},
+	"EXEC":      {OpExecute, CategoryAdmin},
+	"EXECUTE":   {OpExecute, CategoryAdmin},
+	"DO":        {OpDo, CategoryAdmin},
+	"SHOW":      {OpShow, CategoryRead},
+	"DESCRIBE":  {OpDescribe, CategoryRead},
+	"DESC":      {OpDescribe, CategoryRead},
+	"SUMMARIZE": {OpDescribe, CategoryRead},
+}
+
+// adminStatements are keywords that are recognized but never considered safe:
+// they change session or transaction state, run maintenance or load code.
+var adminStatements = map[string]bool{
+	"SET": true, "RESET": true, "BEGIN": true, "START": true, "COMMIT": true,
+	"END": true, "ROLLBACK": true, "ABORT": true, "SAVEPOINT": true, "RELEASE": true,
+	"PREPARE": true, "DEALLOCATE": true, "DISCARD": true, "LOCK": true, "UNLOCK": true,
+	"VACUUM": true, "ANALYZE": true, "ANALYSE": true, "REINDEX": true, "CLUSTER": true,
+	"CHECKPOINT": true, "ATTACH": true, "DETACH": true, "INSTALL": true, "FORCE": true,
+	"USE": true, "LISTEN": true, "NOTIFY": true, "UNLISTEN": true, "REFRESH": true,
+	"SECURITY": true, "REASSIGN": true, "IMPORT": true, "EXPORT": true, "HANDLER": true,
+	"FLUSH": true, "KILL": true, "OPTIMIZE": true, "REPAIR": true, "PURGE": true,
+}
+
+var modifyingKeywords = map[string]Operation{
+	"INSERT": OpInsert,
+	"UPDATE": OpUpdate,
+	"DELETE": OpDelete,
+	"MERGE":  OpMerge,
+}
+
+// Classify splits sql into statements and classifies each one. It fails
+// closed: unterminated literals, empty input and unknown statements are
+// reported as errors instead of being guessed at.
+func Classify(dialect connection.Dialect, sql string) ([]Statement, error) {
+	tokens, err := tokenize(dialect, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	for _, stmtTokens := range splitStatements(tokens) {
+		stmt, err := classifyStatement(dialect, stmtTokens)
+		if err != nil {
+			return nil, err
+		}
+		stmt.SQL = strings.TrimSpace(sql[stmtTokens[0].start:stmtTokens[len(stmtTokens)-1].end])
+		statements = append(statements, stmt)
+	}
+
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("no SQL statement found")
+	}
+
+	return statements, nil
+}
+
+// splitStatements breaks the token stream on top-level semicolons. Inside
+// CREATE TRIGGER/FUNCTION/PROCEDURE/EVENT statements, BEGIN ... END blocks
+// are kept together since their bodies contain semicolons of their own.
+func splitStatements(tokens []token) [][]token {
+	var statements [][]token
+	var current []token
+	blockDepth := 0
+	routine := false
+
+	for i, tok := range tokens {
+		if tok.isPunct(";") && blockDepth == 0 {
+			if len(current) > 0 {
+				statements = append(statements, current)
+			}
+			current = nil
+			routine = false
+			continue
+		}
+
+		current = append(current, tok)
+
+		if !current[0].is("CREATE") {
+			continue
+		}
+		switch {
+		case tok.is("TRIGGER") || tok.is("FUNCTION") || tok.is("PROCEDURE") || tok.is("EVENT"):
+			routine = true
+		case !routine:
+		case tok.is("BEGIN") || tok.is("CASE"):
+			blockDepth++
+		case tok.is("END") && blockDepth > 0:
+			if i+1 < len(tokens) && isCompoundEnd(tokens[i+1]) {
+				continue
+			}
+			blockDepth--
+		}
+	}
+
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+
+	return statements
+}
+
+// isCompoundEnd reports whether the token after END closes a MySQL control
+// structure (END IF, END LOOP, ...) rather than a BEGIN or CASE.
+func isCompoundEnd(tok token) bool {
+	return tok.is("IF") || tok.is("LOOP") || tok.is("WHILE") || tok.is("REPEAT")
+}
+
+func classifyStatement(dialect connection.Dialect, tokens []token) (Statement, error) {
+	tokens = skipExplain(tokens)
+	tokens = trimLeadingParens(tokens)
+
+	if len(tokens) == 0 {
+		return Statement{}, fmt.Errorf("empty statement")
+	}
+
+	first := tokens[0]
+	if first.kind != tokenWord {
+		return Statement{}, fmt.Errorf("unrecognized statement starting with %q", first.value)
+	}
+
+	stmt := Statement{}
+	switch {
+	case first.is("SELECT") || first.is("VALUES") || first.is("TABLE") || first.is("FROM"):
+		stmt.add(classifySelect(dialect, tokens))
+	case first.is("WITH"):
+		op, category, err := classifyWith(dialect, tokens)
+		if err != nil {
+			return Statement{}, err
+		}
+		stmt.add(op, category)
+	case first.is("COPY"):
+		stmt.add(OpCopy, classifyCopy(tokens))
+	case first.is("LOAD"):
+		stmt.add(classifyLoad(tokens))
+	case first.is("PRAGMA"):
+		stmt.add(OpPragma, classifyPragma(tokens))
+	case adminStatements[first.value]:
+		stmt.add(Operation(first.value), CategoryAdmin)
+	default:
+		simple, ok := simpleStatements[first.value]
+		if !ok {
+			return Statement{}, fmt.Errorf("unrecognized statement starting with %q", first.value)
+		}
+		stmt.add(simple.op, simple.category)
+	}
+
+	// Data-modifying subqueries such as WITH x AS (DELETE ... RETURNING *)
+	// or INSERT ... SELECT inside parentheses.
+	for i := 1; i < len(tokens); i++ {
+		if !tokens[i-1].isPunct("(") {
+			continue
+		}
+		if op, ok := modifyingKeywords[tokens[i].value]; ok && tokens[i].kind == tokenWord {
+			stmt.add(op, CategoryWrite)
+		}
+	}
+
+	return stmt, nil
+}
+
+func (s *Statement) add(op Operation, category Category) {
+	if !slices.Contains(s.Operations, op) {
+		s.Operations = append(s.Operations, op)
+	}
+
+	if s.Category == "" || categoryRank[category] > categoryRank[s.Category] {
+		s.Operation = op
+		s.Category = category
+	}
+}
+
+// skipExplain drops an EXPLAIN prefix so the explained statement is
+// classified; EXPLAIN ANALYZE executes it, so it needs the same permissions.
+// MySQL accepts DESC and DESCRIBE as synonyms of EXPLAIN.
+func skipExplain(tokens []token) []token {
+	if len(tokens) == 0 || !(tokens[0].is("EXPLAIN") || isDescribeExplain(tokens)) {
+		return tokens
+	}
+
+	i := 1
+	if i < len(tokens) && tokens[i].isPunct("(") {
+		depth := 0
+		for ; i < len(tokens); i++ {
+			if tokens[i].isPunct("(") {
+				depth++
+			} else if tokens[i].isPunct(")") {
+				depth--
+				if depth == 0 {
+					i++
+					break
+				}
+			}
+		}
+	}
+
+	for i < len(tokens) && isExplainOption(tokens[i]) {
+		i++
+	}
+
+	return tokens[i:]
+}
+
+// explainedStatements are the statements DESC and DESCRIBE explain instead
+// of describing a table.
+var explainedStatements = map[string]bool{
+	"SELECT": true, "TABLE": true, "VALUES": true, "WITH": true,
+	"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true,
+}
+
+// isDescribeExplain reports whether a DESC or DESCRIBE statement is an
+// EXPLAIN in disguise, i.e. is followed by an explain option, FORMAT = ...,
+// or a statement rather than a table name.
+func isDescribeExplain(tokens []token) bool {
+	if len(tokens) < 2 || !(tokens[0].is("DESC") || tokens[0].is("DESCRIBE")) {
+		return false
+	}
+
+	next := tokens[1]
+	switch {
+	case next.isPunct("("):
+		return true
+	case next.is("FORMAT"):
+		return len(tokens) > 2 && tokens[2].isPunct("=")
+	case next.is("ANALYZE"), next.is("EXTENDED"), next.is("PARTITIONS"):
+		return true
+	}
+	return next.kind == tokenWord && explainedStatements[next.value]
+}
+
+func isExplainOption(tok token) bool {
+	switch {
+	case tok.is("ANALYZE"), tok.is("ANALYSE"), tok.is("VERBOSE"), tok.is("QUERY"),
+		tok.is("PLAN"), tok.is("EXTENDED"), tok.is("PARTITIONS"), tok.is("FORMAT"):
+		return true
+	case tok.isPunct("="):
+		return true
+	case tok.is("JSON"), tok.is("TREE"), tok.is("TRADITIONAL"):
+		return true
+	}
+	return false
+}
+
+func trimLeadingParens(tokens []token) []token {
+	for len(tokens) > 0 && tokens[0].isPunct("(") {
+		tokens = tokens[1:]
+	}
+	return tokens
+}
+
+// classifySelect detects SELECT ... INTO, which creates a table in
+// PostgreSQL and DuckDB and writes a server-side file in MySQL (INTO
+// OUTFILE/DUMPFILE). MySQL's INTO @variable is a plain read.
+func classifySelect(dialect connection.Dialect, tokens []token) (Operation, Category) {
+	depth := 0
+	for i, tok := range tokens {
+		switch {
+		case tok.isPunct("("):
+			depth++
+		case tok.isPunct(")"):
+			depth--
+		case depth == 0 && tok.is("INTO"):
+			if dialect != connection.MySQL {
+				return OpSelectInto, CategoryDDL
+			}
+			if i+1 < len(tokens) && (tokens[i+1].is("OUTFILE") || tokens[i+1].is("DUMPFILE")) {
+				return OpSelectInto, CategoryWrite
+			}
+		}
+	}
+	return OpSelect, CategoryRead
+}
+
+// classifyWith finds the main statement that follows the CTE list.
+func classifyWith(dialect connection.Dialect, tokens []token) (Operation, Category, error) {
+	depth := 0
+	for i, tok := range tokens {
+		switch {
+		case tok.isPunct("("):
+			depth++
+		case tok.isPunct(")"):
+			depth--
+		case depth == 0 && tok.kind == tokenWord:
+			if tok.is("SELECT") || tok.is("VALUES") || tok.is("TABLE") {
+				op, category := classifySelect(dialect, tokens[i:])
+				return op, category, nil
+			}
+			if simple, ok := simpleStatements[tok.value]; ok && simple.category == CategoryWrite {
+				return simple.op, simple.category, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("unrecognized statement after WITH clause")
+}
+
+// classifyCopy treats COPY ... TO STDOUT as a read, COPY TO or FROM PROGRAM,
+// which runs a shell command on the server, as an admin command and
+// everything else (COPY FROM, COPY TO a server-side file) as a write.
+func classifyCopy(tokens []token) Category {
+	depth := 0
+	for i, tok := range tokens {
+		switch {
+		case tok.isPunct("("):
+			depth++
+		case tok.isPunct(")"):
+			depth--
+		case depth == 0 && (tok.is("TO") || tok.is("FROM")):
+			if i+1 < len(tokens) && tokens[i+1].is("PROGRAM") {
+				return CategoryAdmin
+			}
+			if tok.is("TO") && i+1 < len(tokens) && tokens[i+1].is("STDOUT") {
+				return CategoryRead
+			}
+			return CategoryWrite
+		}
+	}
+	return CategoryWrite
+}
+
+// classifyLoad distinguishes MySQL LOAD DATA/XML, which inserts rows, from
+// DuckDB's LOAD extension, which loads code into the server.
+func classifyLoad(tokens []token) (Operation, Category) {
+	for _, tok := range tokens[1:] {
+		if tok.is("DATA") || tok.is("XML") {
+			return OpLoad, CategoryWrite
+		}
+	}
+	return OpLoad, CategoryAdmin
+}
+
+// readOnlyPragmas are the pragmas that only report state when queried
+// without an argument (PRAGMA user_version). Many others, such as optimize,
+// wal_checkpoint or incremental_vacuum, act when named, so anything not
+// listed is an admin command.
+var readOnlyPragmas = map[string]bool{
+	"APPLICATION_ID": true, "AUTO_VACUUM": true, "AUTOMATIC_INDEX": true,
+	"BUSY_TIMEOUT": true, "CACHE_SIZE": true, "CACHE_SPILL": true,
+	"CELL_SIZE_CHECK": true, "CHECKPOINT_FULLFSYNC": true, "COLLATION_LIST": true,
+	"COMPILE_OPTIONS": true, "DATA_VERSION": true, "DATABASE_LIST": true,
+	"DEFER_FOREIGN_KEYS": true, "ENCODING": true, "FOREIGN_KEY_CHECK": true,
+	"FOREIGN_KEYS": true, "FREELIST_COUNT": true, "FULLFSYNC": true,
+	"FUNCTION_LIST": true, "IGNORE_CHECK_CONSTRAINTS": true, "INTEGRITY_CHECK": true,
+	"JOURNAL_MODE": true, "JOURNAL_SIZE_LIMIT": true, "LEGACY_ALTER_TABLE": true,
+	"LOCKING_MODE": true, "MAX_PAGE_COUNT": true, "MMAP_SIZE": true,
+	"MODULE_LIST": true, "PAGE_COUNT": true, "PAGE_SIZE": true,
+	"PRAGMA_LIST": true, "QUERY_ONLY": true, "QUICK_CHECK": true,
+	"READ_UNCOMMITTED": true, "RECURSIVE_TRIGGERS": true, "REVERSE_UNORDERED_SELECTS": true,
+	"SCHEMA_VERSION": true, "SECURE_DELETE": true, "SYNCHRONOUS": true,
+	"TABLE_LIST": true, "TEMP_STORE": true, "THREADS": true,
+	"TRUSTED_SCHEMA": true, "USER_VERSION": true, "WAL_AUTOCHECKPOINT": true,
+	// DuckDB
+	"DATABASE_SIZE": true, "SHOW_TABLES": true, "SHOW_TABLES_EXPANDED": true,
+	"VERSION": true, "PLATFORM": true, "FUNCTIONS": true, "COLLATIONS": true,
+	"USER_AGENT": true, "METADATA_INFO": true,
+}
+
+// readOnlyPragmaCalls are the pragmas that take an argument without
+// changing any state.
+var readOnlyPragmaCalls = map[string]bool{
+	"TABLE_INFO": true, "TABLE_XINFO": true, "TABLE_LIST": true,
+	"INDEX_LIST": true, "INDEX_INFO": true, "INDEX_XINFO": true,
+	"FOREIGN_KEY_LIST": true, "FOREIGN_KEY_CHECK": true,
+	"INTEGRITY_CHECK": true, "QUICK_CHECK": true,
+	// DuckDB
+	"SHOW": true, "STORAGE_INFO": true,
+}
+
+// classifyPragma treats the allowlisted lookups (PRAGMA user_version) and
+// introspection calls (PRAGMA table_info(t)) as reads. Assignments and every
+// other pragma may change state and are admin commands.
+func classifyPragma(tokens []token) Category {
+	var name string
+	for _, tok := range tokens[1:] {
+		switch {
+		case tok.isPunct("="):
+			return CategoryAdmin
+		case tok.isPunct("("):
+			if readOnlyPragmaCalls[name] {
+				return CategoryRead
+			}
+			return CategoryAdmin
+		case tok.kind == tokenWord:
+			name = tok.value
+		}
+	}
+
+	if readOnlyPragmas[name] {
+		return CategoryRead
+	}
+	return CategoryAdmin
+}
@@ -0,0 +1,126 @@
+package classifier
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		dialect    connection.Dialect
+		sql        string
+		categories []Category
+		operations []Operation
+	}{
+		// Multi-statement splitting
+		{"single select", connection.PostgreSQL, "SELECT 1", []Category{CategoryRead}, nil},
+		{"two statements", connection.PostgreSQL, "SELECT 1; DELETE FROM t", []Category{CategoryRead, CategoryWrite}, nil},
+		{"trailing semicolons", connection.PostgreSQL, "SELECT 1;;", []Category{CategoryRead}, nil},
+		{"semicolon in string", connection.PostgreSQL, "SELECT ';DELETE FROM t'", []Category{CategoryRead}, nil},
+		{"semicolon in comment", connection.PostgreSQL, "SELECT 1 /* ; DROP TABLE t */", []Category{CategoryRead}, nil},
+		{"commit then delete", connection.PostgreSQL, "COMMIT; DELETE FROM t", []Category{CategoryAdmin, CategoryWrite}, nil},
+		{"mysql trigger body", connection.MySQL, "CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.x = 1; END", []Category{CategoryDDL}, nil},
+
+		// CTEs
+		{"cte select", connection.PostgreSQL, "WITH x AS (SELECT 1) SELECT * FROM x", []Category{CategoryRead}, nil},
+		{"cte with delete", connection.PostgreSQL, "WITH x AS (DELETE FROM t RETURNING *) SELECT * FROM x", []Category{CategoryWrite}, []Operation{OpSelect, OpDelete}},
+		{"cte feeding insert", connection.PostgreSQL, "WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", []Category{CategoryWrite}, []Operation{OpInsert}},
+		{"select into", connection.PostgreSQL, "SELECT * INTO t2 FROM t", []Category{CategoryDDL}, nil},
+		{"mysql select into outfile", connection.MySQL, "SELECT * FROM t INTO OUTFILE '/tmp/x'", []Category{CategoryWrite}, nil},
+		{"mysql select into variable", connection.MySQL, "SELECT x INTO @v FROM t", []Category{CategoryRead}, nil},
+
+		// EXPLAIN
+		{"explain select", connection.PostgreSQL, "EXPLAIN SELECT 1", []Category{CategoryRead}, nil},
+		{"explain analyze delete", connection.PostgreSQL, "EXPLAIN ANALYZE DELETE FROM t", []Category{CategoryWrite}, []Operation{OpDelete}},
+		{"explain options update", connection.PostgreSQL, "EXPLAIN (ANALYZE, BUFFERS) UPDATE t SET x = 1", []Category{CategoryWrite}, []Operation{OpUpdate}},
+		{"mysql desc analyze delete", connection.MySQL, "DESC ANALYZE DELETE t FROM t JOIN u ON t.id = u.id", []Category{CategoryWrite}, []Operation{OpDelete}},
+		{"mysql describe analyze update", connection.MySQL, "DESCRIBE ANALYZE UPDATE t SET x = 1", []Category{CategoryWrite}, []Operation{OpUpdate}},
+		{"mysql desc format update", connection.MySQL, "DESC FORMAT=JSON UPDATE t SET x = 1", []Category{CategoryWrite}, []Operation{OpUpdate}},
+		{"mysql describe insert", connection.MySQL, "DESCRIBE INSERT INTO t VALUES (1)", []Category{CategoryWrite}, []Operation{OpInsert}},
+		{"mysql desc select", connection.MySQL, "DESC SELECT * FROM t", []Category{CategoryRead}, []Operation{OpSelect}},
+		{"mysql desc table", connection.MySQL, "DESC t", []Category{CategoryRead}, []Operation{OpDescribe}},
+		{"mysql describe table named format", connection.MySQL, "DESCRIBE format", []Category{CategoryRead}, []Operation{OpDescribe}},
+
+		// COPY
+		{"copy to stdout", connection.PostgreSQL, "COPY t TO STDOUT", []Category{CategoryRead}, nil},
+		{"copy query to stdout", connection.PostgreSQL, "COPY (SELECT * FROM t) TO STDOUT", []Category{CategoryRead}, nil},
+		{"copy to file", connection.PostgreSQL, "COPY t TO '/tmp/t.csv'", []Category{CategoryWrite}, nil},
+		{"copy from file", connection.PostgreSQL, "COPY t FROM '/tmp/t.csv'", []Category{CategoryWrite}, nil},
+		{"copy to program", connection.PostgreSQL, "COPY t TO PROGRAM 'rm -rf /tmp/x'", []Category{CategoryAdmin}, []Operation{OpCopy}},
+		{"copy query to program", connection.PostgreSQL, "COPY (SELECT 1) TO PROGRAM 'id'", []Category{CategoryAdmin}, nil},
+		{"copy from program", connection.PostgreSQL, "COPY t FROM PROGRAM 'curl http://x'", []Category{CategoryAdmin}, nil},
+
+		// Dollar quotes
+		{"dollar quoted semicolon", connection.PostgreSQL, "SELECT $$;DELETE FROM t$$", []Category{CategoryRead}, nil},
+		{"tagged dollar quote", connection.PostgreSQL, "SELECT $tag$ $$; DROP TABLE t $tag$; DELETE FROM t", []Category{CategoryRead, CategoryWrite}, nil},
+		{"positional parameter", connection.PostgreSQL, "SELECT $1", []Category{CategoryRead}, nil},
+		{"do block", connection.PostgreSQL, "DO $$ BEGIN DELETE FROM t; END $$", []Category{CategoryAdmin}, nil},
+
+		// MySQL executable comments
+		{"executable comment", connection.MySQL, "SELECT 1 /*! ; DELETE FROM t */", []Category{CategoryRead, CategoryWrite}, nil},
+		{"versioned executable comment", connection.MySQL, "/*!50000 DELETE FROM t */", []Category{CategoryWrite}, nil},
+		{"plain comment in mysql", connection.MySQL, "SELECT 1 /* ; DELETE FROM t */", []Category{CategoryRead}, nil},
+
+		// Pragmas
+		{"pragma lookup", connection.SQLite, "PRAGMA user_version", []Category{CategoryRead}, nil},
+		{"pragma schema lookup", connection.SQLite, "PRAGMA main.page_count", []Category{CategoryRead}, nil},
+		{"pragma table info", connection.SQLite, "PRAGMA table_info(t)", []Category{CategoryRead}, nil},
+		{"pragma assignment", connection.SQLite, "PRAGMA user_version = 3", []Category{CategoryAdmin}, nil},
+		{"pragma call with side effects", connection.SQLite, "PRAGMA wal_checkpoint(TRUNCATE)", []Category{CategoryAdmin}, nil},
+		{"pragma optimize", connection.SQLite, "PRAGMA optimize", []Category{CategoryAdmin}, nil},
+		{"pragma wal checkpoint", connection.SQLite, "PRAGMA wal_checkpoint", []Category{CategoryAdmin}, nil},
+		{"pragma incremental vacuum", connection.SQLite, "PRAGMA incremental_vacuum", []Category{CategoryAdmin}, nil},
+		{"pragma shrink memory", connection.SQLite, "PRAGMA shrink_memory", []Category{CategoryAdmin}, nil},
+		{"pragma unknown", connection.SQLite, "PRAGMA something_new", []Category{CategoryAdmin}, nil},
+		{"duckdb pragma show tables", connection.DuckDB, "PRAGMA show_tables", []Category{CategoryRead}, nil},
+		{"duckdb pragma enable profiling", connection.DuckDB, "PRAGMA enable_profiling", []Category{CategoryAdmin}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := Classify(tt.dialect, tt.sql)
+			if err != nil {
+				t.Fatalf("Classify(%q): %v", tt.sql, err)
+			}
+
+			var categories []Category
+			for _, stmt := range statements {
+				categories = append(categories, stmt.Category)
+			}
+			if !slices.Equal(categories, tt.categories) {
+				t.Errorf("Classify(%q) categories = %v, want %v", tt.sql, categories, tt.categories)
+			}
+
+			for _, op := range tt.operations {
+				if !slices.Contains(statements[0].Operations, op) {
+					t.Errorf("Classify(%q) operations = %v, missing %s", tt.sql, statements[0].Operations, op)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect connection.Dialect
+		sql     string
+	}{
+		{"empty", connection.PostgreSQL, "   ;  "},
+		{"unterminated string", connection.PostgreSQL, "SELECT 'abc"},
+		{"unterminated dollar quote", connection.PostgreSQL, "SELECT $$abc"},
+		{"unterminated executable comment", connection.MySQL, "SELECT 1 /*! DELETE FROM t"},
+		{"unknown statement", connection.PostgreSQL, "FROBNICATE t"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if statements, err := Classify(tt.dialect, tt.sql); err == nil {
+				t.Errorf("Classify(%q) = %+v, want error", tt.sql, statements)
+			}
+		})
+	}
+}
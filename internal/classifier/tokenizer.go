@@ -0,0 +1,282 @@
+package classifier
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenQuoted
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	start int
+	end   int
+}
+
+func (t token) is(word string) bool {
+	return t.kind == tokenWord && t.value == word
+}
+
+func (t token) isPunct(p string) bool {
+	return t.kind == tokenPunct && t.value == p
+}
+
+// tokenizer splits SQL into words, quoted literals/identifiers and punctuation.
+// Comments and whitespace are dropped. The quoting rules follow the dialect,
+// since getting them wrong would let a statement hide inside what we believe
+// to be a string or a comment.
+type tokenizer struct {
+	dialect connection.Dialect
+	src     string
+	pos     int
+	// execComment is set while inside a MySQL /*! ... */ comment, whose
+	// content is executed by the server and therefore must be tokenized.
+	execComment bool
+}
+
+func tokenize(dialect connection.Dialect, src string) ([]token, error) {
+	t := &tokenizer{dialect: dialect, src: src}
+
+	var tokens []token
+	for {
+		tok, ok, err := t.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		tokens = append(tokens, tok)
+	}
+
+	if t.execComment {
+		return nil, fmt.Errorf("unterminated comment")
+	}
+
+	return tokens, nil
+}
+
+func (t *tokenizer) peek(offset int) byte {
+	if t.pos+offset >= len(t.src) {
+		return 0
+	}
+	return t.src[t.pos+offset]
+}
+
+func (t *tokenizer) next() (token, bool, error) {
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+
+		switch {
+		case isSpace(c):
+			t.pos++
+
+		case c == '-' && t.peek(1) == '-' && t.isLineComment():
+			t.skipLine()
+
+		case c == '#' && t.dialect == connection.MySQL:
+			t.skipLine()
+
+		case c == '/' && t.peek(1) == '*':
+			if t.dialect == connection.MySQL && t.peek(2) == '!' {
+				t.pos += 3
+				for t.pos < len(t.src) && isDigit(t.src[t.pos]) {
+					t.pos++
+				}
+				t.execComment = true
+				continue
+			}
+			if err := t.skipBlockComment(); err != nil {
+				return token{}, false, err
+			}
+
+		case c == '*' && t.peek(1) == '/' && t.execComment:
+			t.pos += 2
+			t.execComment = false
+
+		case c == '\'':
+			return t.readQuoted('\'', t.dialect == connection.MySQL)
+
+		case (c == 'E' || c == 'e') && t.peek(1) == '\'' && t.supportsEscapeStrings():
+			t.pos++
+			tok, ok, err := t.readQuoted('\'', true)
+			tok.start--
+			return tok, ok, err
+
+		case c == '"':
+			return t.readQuoted('"', t.dialect == connection.MySQL)
+
+		case c == '`' && (t.dialect == connection.MySQL || t.dialect == connection.SQLite):
+			return t.readQuoted('`', false)
+
+		case c == '[' && t.dialect == connection.SQLite:
+			return t.readBracketIdentifier()
+
+		case c == '$' && t.supportsDollarQuotes():
+			if tok, ok, err := t.readDollarQuoted(); ok || err != nil {
+				return tok, ok, err
+			}
+			return t.readPunct()
+
+		case isWordStart(c):
+			return t.readWord()
+
+		default:
+			return t.readPunct()
+		}
+	}
+
+	return token{}, false, nil
+}
+
+// isLineComment reports whether the "--" at the current position starts a
+// comment. MySQL only treats it as one when followed by whitespace, so
+// "1--1" is arithmetic there.
+func (t *tokenizer) isLineComment() bool {
+	if t.dialect != connection.MySQL {
+		return true
+	}
+	after := t.peek(2)
+	return after == 0 || isSpace(after) || after < 0x20
+}
+
+func (t *tokenizer) supportsEscapeStrings() bool {
+	return t.dialect == connection.PostgreSQL || t.dialect == connection.DuckDB
+}
+
+func (t *tokenizer) supportsDollarQuotes() bool {
+	return t.dialect == connection.PostgreSQL || t.dialect == connection.DuckDB
+}
+
+func (t *tokenizer) skipLine() {
+	for t.pos < len(t.src) && t.src[t.pos] != '\n' {
+		t.pos++
+	}
+}
+
+func (t *tokenizer) skipBlockComment() error {
+	nested := t.dialect == connection.PostgreSQL || t.dialect == connection.DuckDB
+	depth := 0
+
+	for t.pos < len(t.src) {
+		switch {
+		case t.src[t.pos] == '/' && t.peek(1) == '*':
+			if depth == 0 || nested {
+				depth++
+			}
+			t.pos += 2
+		case t.src[t.pos] == '*' && t.peek(1) == '/':
+			depth--
+			t.pos += 2
+			if depth == 0 {
+				return nil
+			}
+		default:
+			t.pos++
+		}
+	}
+
+	return fmt.Errorf("unterminated block comment")
+}
+
+func (t *tokenizer) readQuoted(quote byte, backslashEscapes bool) (token, bool, error) {
+	start := t.pos
+	t.pos++
+
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		switch {
+		case c == '\\' && backslashEscapes:
+			t.pos += 2
+		case c == quote && t.peek(1) == quote:
+			t.pos += 2
+		case c == quote:
+			t.pos++
+			return token{kind: tokenQuoted, value: t.src[start:t.pos], start: start, end: t.pos}, true, nil
+		default:
+			t.pos++
+		}
+	}
+
+	return token{}, false, fmt.Errorf("unterminated quoted string starting at offset %d", start)
+}
+
+func (t *tokenizer) readBracketIdentifier() (token, bool, error) {
+	start := t.pos
+	end := strings.IndexByte(t.src[start:], ']')
+	if end < 0 {
+		return token{}, false, fmt.Errorf("unterminated identifier starting at offset %d", start)
+	}
+	t.pos = start + end + 1
+	return token{kind: tokenQuoted, value: t.src[start:t.pos], start: start, end: t.pos}, true, nil
+}
+
+// readDollarQuoted consumes a $tag$...$tag$ string. It returns ok=false
+// without consuming input when the "$" does not open a dollar quote (e.g. a
+// positional parameter such as $1).
+func (t *tokenizer) readDollarQuoted() (token, bool, error) {
+	start := t.pos
+	i := start + 1
+	for i < len(t.src) && isTagChar(t.src[i]) {
+		i++
+	}
+	if i >= len(t.src) || t.src[i] != '$' {
+		return token{}, false, nil
+	}
+	if i > start+1 && isDigit(t.src[start+1]) {
+		return token{}, false, nil
+	}
+
+	tag := t.src[start : i+1]
+	end := strings.Index(t.src[i+1:], tag)
+	if end < 0 {
+		return token{}, false, fmt.Errorf("unterminated dollar-quoted string starting at offset %d", start)
+	}
+
+	t.pos = i + 1 + end + len(tag)
+	return token{kind: tokenQuoted, value: t.src[start:t.pos], start: start, end: t.pos}, true, nil
+}
+
+func (t *tokenizer) readWord() (token, bool, error) {
+	start := t.pos
+	for t.pos < len(t.src) && isWordChar(t.src[t.pos]) {
+		t.pos++
+	}
+	return token{kind: tokenWord, value: strings.ToUpper(t.src[start:t.pos]), start: start, end: t.pos}, true, nil
+}
+
+func (t *tokenizer) readPunct() (token, bool, error) {
+	start := t.pos
+	t.pos++
+	return token{kind: tokenPunct, value: t.src[start:t.pos], start: start, end: t.pos}, true, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)) || isDigit(c)
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || c == '$'
+}
+
+func isTagChar(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)) || isDigit(c)
+}
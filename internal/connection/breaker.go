@@ -0,0 +1,52 @@
+package connection
+
+import "time"
+
+const (
+	DefaultBreakerThreshold = 3
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// breaker counts consecutive failures to reach the database of one
+// connection, from health checks or from Acquire opening an adapter. After
+// threshold failures it opens and Acquire fails fast; once the cooldown has
+// passed it is half-open and lets requests through again until the next
+// health check or open either closes it or opens it for another cooldown.
+type breaker struct {
+	failures  int
+	openedAt  time.Time
+	lastError string
+}
+
+func (b *breaker) state(threshold int, cooldown time.Duration) BreakerState {
+	if threshold <= 0 || b.failures < threshold {
+		return BreakerClosed
+	}
+	if time.Since(b.openedAt) < cooldown {
+		return BreakerOpen
+	}
+	return BreakerHalfOpen
+}
+
+func (b *breaker) record(err error, threshold int) {
+	if err == nil {
+		b.failures = 0
+		b.lastError = ""
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	if threshold > 0 && b.failures >= threshold {
+		// Opening again from half-open restarts the cooldown.
+		b.openedAt = time.Now()
+	}
+}
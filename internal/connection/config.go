@@ -2,15 +2,48 @@ package connection
 
 import (
 	"strconv"
+	"time"
 
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
 	"github.com/CaioMtho/pinoql-mcp/internal/errors"
 	_ "github.com/CaioMtho/pinoql-mcp/internal/errors"
 )
 
 type Config struct {
+	// ID identifies the stored connection the adapter is pooled under.
+	ID       string
+	TenantID string
 	Dialect  Dialect
 	DSN      string
 	ReadOnly bool
+	Pool     PoolSettings
+}
+
+// PoolSettings are applied to the adapter's sql.DB. Zero values follow
+// database/sql semantics: no limit on open connections or connection age.
+type PoolSettings struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
+	ConnMaxLifetime time.Duration
+}
+
+// FromConnectionData builds the adapter config of a stored connection, which
+// must have been loaded with its decrypted DSN.
+func FromConnectionData(conn *connection_data.ConnectionData) Config {
+	return Config{
+		ID:       conn.ID,
+		TenantID: conn.TenantID,
+		Dialect:  Dialect(conn.Dialect),
+		DSN:      conn.DSN,
+		ReadOnly: conn.ReadOnly,
+		Pool: PoolSettings{
+			MaxOpenConns:    conn.MaxConnections,
+			MaxIdleConns:    conn.MaxIdleConnections,
+			ConnMaxIdleTime: time.Duration(conn.ConnMaxIdleTime) * time.Second,
+			ConnMaxLifetime: time.Duration(conn.ConnMaxLifetime) * time.Second,
+		},
+	}
 }
 
 func FromRaw(dialectString string, dsn string, readonlyString string) (*Config, error) {
@@ -1,68 +1,558 @@
 package connection
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"sync"
 	"time"
 
 	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters/duckdb"
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters/mysql"
 	"github.com/CaioMtho/pinoql-mcp/internal/adapters/postgres"
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters/sqlite"
 	"github.com/CaioMtho/pinoql-mcp/internal/errors"
 )
 
+var errManagerClosed = fmt.Errorf("connection manager is closed")
+
+const (
+	DefaultMaxPools          = 100
+	DefaultMaxPoolsPerTenant = 20
+	DefaultPoolIdleTimeout   = 10 * time.Minute
+
+	// openTimeout bounds the ping of a newly opened adapter.
+	openTimeout = 10 * time.Second
+)
+
+// ManagerConfig bounds how many pools stay open. When a limit is reached the
+// least recently used pool is evicted, preferring pools nobody is using.
+// Zero limits mean unlimited and a zero IdleTimeout disables idle eviction.
+// A zero BreakerThreshold disables the circuit breaker.
+type ManagerConfig struct {
+	MaxPools          int
+	MaxPoolsPerTenant int
+	IdleTimeout       time.Duration
+	BreakerThreshold  int
+	BreakerCooldown   time.Duration
+	Files             FilePolicy
+}
+
+// ManagerStats is a snapshot of the pools and of why they were closed.
+type ManagerStats struct {
+	OpenPools            int            `json:"open_pools"`
+	RetiringPools        int            `json:"retiring_pools"`
+	PoolsPerTenant       map[string]int `json:"pools_per_tenant"`
+	Opened               int64          `json:"opened"`
+	Invalidations        int64          `json:"invalidations"`
+	IdleEvictions        int64          `json:"idle_evictions"`
+	LimitEvictions       int64          `json:"limit_evictions"`
+	TenantLimitEvictions int64          `json:"tenant_limit_evictions"`
+	OpenCircuits         int            `json:"open_circuits"`
+}
+
+// Manager keeps one adapter, and so one pool, per stored connection.
 type Manager struct {
+	cfg ManagerConfig
+
 	mu       sync.Mutex
-	adapters map[string]adapters.Adapter
+	adapters map[string]*managedAdapter
+	breakers map[string]*breaker
+	stats    ManagerStats
+	// retiring tracks adapters that were dropped but are still draining, so
+	// CloseAll can wait for them.
+	retiring sync.WaitGroup
+	// stopJanitor stops the goroutine started by StartJanitor and waits for
+	// it to return.
+	stopJanitor func()
+	// closed is set by CloseAll, after which Acquire fails. closeErr is the
+	// first error closing an adapter from then on.
+	closed   bool
+	closeErr error
+}
+
+// managedAdapter remembers what an adapter was opened with. Settings are
+// read from the stored connection on every use, so a changed DSN or dialect
+// reopens the adapter and changed pool settings are applied in place,
+// without a restart.
+type managedAdapter struct {
+	adapter  adapters.Adapter
+	tenantID string
+	dialect  Dialect
+	dsn      string
+	readOnly bool
+	pool     PoolSettings
+
+	// The fields below are guarded by the manager lock.
+	users    int
+	lastUsed time.Time
+	retired  bool
+	drained  chan struct{}
 }
 
-func NewConnectionManager() *Manager {
+func NewConnectionManager(cfg ManagerConfig) *Manager {
 	return &Manager{
-		adapters: make(map[string]adapters.Adapter),
+		cfg:      cfg,
+		adapters: make(map[string]*managedAdapter),
+		breakers: make(map[string]*breaker),
 	}
 }
 
-func (cm *Manager) GetAdapter(cfg Config) (adapters.Adapter, error) {
-	key := string(cfg.Dialect) + "|" + cfg.DSN
+// Acquire returns the adapter for cfg, opening it on first use. The caller
+// must call release once it is done with the adapter, including reading any
+// rows; a dropped adapter is only closed after every caller released it.
+// A new adapter is pinged before it is pooled, and failures to open or reach
+// the database count against the connection's circuit breaker. While the
+// circuit is open it fails fast with a CircuitOpenError instead of waiting
+// on an unreachable database.
+func (cm *Manager) Acquire(ctx context.Context, cfg Config) (adapters.Adapter, func(), error) {
+	if cfg.ID == "" {
+		return nil, nil, fmt.Errorf("connection ID is required")
+	}
+
+	cm.mu.Lock()
+
+	if cm.closed {
+		cm.mu.Unlock()
+		return nil, nil, errManagerClosed
+	}
+
+	if b, ok := cm.breakers[cfg.ID]; ok && b.state(cm.cfg.BreakerThreshold, cm.cfg.BreakerCooldown) == BreakerOpen {
+		cm.mu.Unlock()
+		return nil, nil, errors.CircuitOpenError{
+			ConnectionID: cfg.ID,
+			Failures:     b.failures,
+			LastError:    b.lastError,
+			RetryAt:      b.openedAt.Add(cm.cfg.BreakerCooldown),
+		}
+	}
+
+	if managed, ok := cm.pooled(cfg); ok {
+		defer cm.mu.Unlock()
+		return cm.use(managed, cfg.Pool)
+	}
+
+	cm.mu.Unlock()
+
+	// Dialing can take as long as the database takes to answer, so it
+	// happens without holding the lock.
+	adapter, err := openPinged(ctx, cfg, cm.cfg.Files)
 
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	if adapter, ok := cm.adapters[key]; ok {
-		return adapter, nil
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up; that says nothing about the database.
+		return nil, nil, err
+	}
+	cm.recordResult(cfg.ID, err)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cm.closed {
+		go closeAdapter(cfg.ID, adapter)
+		return nil, nil, errManagerClosed
+	}
+
+	// Another caller may have opened the connection meanwhile.
+	if managed, ok := cm.pooled(cfg); ok {
+		go closeAdapter(cfg.ID, adapter)
+		return cm.use(managed, cfg.Pool)
+	}
+
+	cm.makeRoom(cfg.TenantID)
+
+	managed := &managedAdapter{
+		adapter:  adapter,
+		tenantID: cfg.TenantID,
+		dialect:  cfg.Dialect,
+		dsn:      cfg.DSN,
+		readOnly: cfg.ReadOnly,
+		pool:     cfg.Pool,
+		drained:  make(chan struct{}),
+	}
+	cm.adapters[cfg.ID] = managed
+	cm.stats.Opened++
+
+	return cm.use(managed, cfg.Pool)
+}
+
+// pooled returns the open adapter of cfg, retiring it instead when it was
+// opened with different settings. It must be called with cm.mu held.
+func (cm *Manager) pooled(cfg Config) (*managedAdapter, bool) {
+	managed, ok := cm.adapters[cfg.ID]
+	if ok && (managed.dialect != cfg.Dialect || managed.dsn != cfg.DSN || managed.readOnly != cfg.ReadOnly) {
+		// Reconfigured without a notification, e.g. by another instance.
+		cm.retire(cfg.ID, managed)
+		cm.stats.Invalidations++
+		return nil, false
+	}
+	return managed, ok
+}
+
+// use hands out managed to a caller, applying changed pool settings in
+// place. It must be called with cm.mu held.
+func (cm *Manager) use(managed *managedAdapter, pool PoolSettings) (adapters.Adapter, func(), error) {
+	if managed.pool != pool {
+		applyPoolSettings(managed.adapter, pool)
+		managed.pool = pool
+	}
+
+	managed.users++
+	managed.lastUsed = time.Now()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			cm.mu.Lock()
+			defer cm.mu.Unlock()
+
+			managed.users--
+			managed.lastUsed = time.Now()
+			if managed.retired && managed.users == 0 {
+				close(managed.drained)
+			}
+		})
+	}
+
+	return managed.adapter, release, nil
+}
+
+// Check pings the database of cfg and feeds the result to its circuit
+// breaker. It reuses the pooled adapter when one is open, without counting
+// as a use for idle eviction, and otherwise dials through a throwaway
+// single-connection adapter so that checks never open pools of their own.
+func (cm *Manager) Check(ctx context.Context, cfg Config) error {
+	if cfg.ID == "" {
+		return fmt.Errorf("connection ID is required")
 	}
 
-	var adapter adapters.Adapter
+	cm.mu.Lock()
+	managed, ok := cm.adapters[cfg.ID]
+	if ok && managed.dialect == cfg.Dialect && managed.dsn == cfg.DSN && managed.readOnly == cfg.ReadOnly {
+		managed.users++
+	} else {
+		managed = nil
+	}
+	cm.mu.Unlock()
+
 	var err error
+	if managed != nil {
+		err = managed.adapter.HealthCheck(ctx)
+
+		cm.mu.Lock()
+		managed.users--
+		if managed.retired && managed.users == 0 {
+			close(managed.drained)
+		}
+		cm.mu.Unlock()
+	} else {
+		err = checkOnce(ctx, cfg, cm.cfg.Files)
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.recordResult(cfg.ID, err)
+	return err
+}
+
+// recordResult feeds the outcome of reaching the database of a connection
+// to its circuit breaker. It must be called with cm.mu held.
+func (cm *Manager) recordResult(connectionID string, err error) {
+	b, ok := cm.breakers[connectionID]
+	if !ok {
+		if err == nil {
+			return
+		}
+		b = &breaker{}
+		cm.breakers[connectionID] = b
+	}
+
+	b.record(err, cm.cfg.BreakerThreshold)
+	if b.failures == 0 {
+		delete(cm.breakers, connectionID)
+	}
+}
+
+// BreakerStatus reports the circuit state of a connection and how many
+// attempts to reach its database in a row have failed.
+func (cm *Manager) BreakerStatus(connectionID string) (BreakerState, int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	b, ok := cm.breakers[connectionID]
+	if !ok {
+		return BreakerClosed, 0
+	}
+
+	return b.state(cm.cfg.BreakerThreshold, cm.cfg.BreakerCooldown), b.failures
+}
+
+// Invalidate drops the adapter of a connection that was changed or deleted,
+// and resets its circuit breaker. Queries already running on it are allowed
+// to finish before its pool is closed; the next Acquire opens a fresh adapter.
+func (cm *Manager) Invalidate(connectionID string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	delete(cm.breakers, connectionID)
+
+	if managed, ok := cm.adapters[connectionID]; ok {
+		cm.retire(connectionID, managed)
+		cm.stats.Invalidations++
+	}
+}
+
+// StartJanitor evicts pools that have been idle for longer than the idle
+// timeout, until ctx is done.
+func (cm *Manager) StartJanitor(ctx context.Context) {
+	if cm.cfg.IdleTimeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	cm.mu.Lock()
+	cm.stopJanitor = func() {
+		cancel()
+		<-done
+	}
+	cm.mu.Unlock()
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(max(cm.cfg.IdleTimeout/4, time.Second))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cm.evictIdle()
+			}
+		}
+	}()
+}
+
+func (cm *Manager) evictIdle() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cutoff := time.Now().Add(-cm.cfg.IdleTimeout)
+	for id, managed := range cm.adapters {
+		if managed.users == 0 && managed.lastUsed.Before(cutoff) {
+			cm.retire(id, managed)
+			cm.stats.IdleEvictions++
+		}
+	}
+}
+
+// makeRoom evicts pools until one more can be opened for tenantID without
+// exceeding the limits. It must be called with cm.mu held.
+func (cm *Manager) makeRoom(tenantID string) {
+	if limit := cm.cfg.MaxPoolsPerTenant; limit > 0 && tenantID != "" {
+		for cm.countPools(tenantID) >= limit {
+			id, managed := cm.leastRecentlyUsed(tenantID)
+			cm.retire(id, managed)
+			cm.stats.TenantLimitEvictions++
+		}
+	}
+
+	if limit := cm.cfg.MaxPools; limit > 0 {
+		for len(cm.adapters) >= limit {
+			id, managed := cm.leastRecentlyUsed("")
+			cm.retire(id, managed)
+			cm.stats.LimitEvictions++
+		}
+	}
+}
+
+func (cm *Manager) countPools(tenantID string) int {
+	count := 0
+	for _, managed := range cm.adapters {
+		if managed.tenantID == tenantID {
+			count++
+		}
+	}
+	return count
+}
+
+// leastRecentlyUsed picks the eviction candidate among the pools of
+// tenantID, or among all pools if tenantID is empty. Idle pools go first;
+// busy ones are only picked when there is nothing else, and then drain
+// before they close.
+func (cm *Manager) leastRecentlyUsed(tenantID string) (string, *managedAdapter) {
+	var candidateID string
+	var candidate *managedAdapter
+
+	for id, managed := range cm.adapters {
+		if tenantID != "" && managed.tenantID != tenantID {
+			continue
+		}
+		if candidate == nil || evictsBefore(managed, candidate) {
+			candidateID, candidate = id, managed
+		}
+	}
+
+	return candidateID, candidate
+}
+
+func evictsBefore(a, b *managedAdapter) bool {
+	if (a.users == 0) != (b.users == 0) {
+		return a.users == 0
+	}
+	return a.lastUsed.Before(b.lastUsed)
+}
+
+// retire removes an adapter from the map and closes it once its last user
+// releases it. It must be called with cm.mu held.
+func (cm *Manager) retire(connectionID string, managed *managedAdapter) {
+	delete(cm.adapters, connectionID)
+	managed.retired = true
+	if managed.users == 0 {
+		close(managed.drained)
+	}
+
+	cm.stats.RetiringPools++
+	cm.retiring.Add(1)
+	go func() {
+		defer cm.retiring.Done()
+		<-managed.drained
+		err := closeAdapter(connectionID, managed.adapter)
+
+		cm.mu.Lock()
+		cm.stats.RetiringPools--
+		if err != nil && cm.closed && cm.closeErr == nil {
+			cm.closeErr = err
+		}
+		cm.mu.Unlock()
+	}()
+}
+
+func (cm *Manager) Stats() ManagerStats {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	stats := cm.stats
+	stats.OpenPools = len(cm.adapters)
+	stats.PoolsPerTenant = map[string]int{}
+	for _, managed := range cm.adapters {
+		stats.PoolsPerTenant[managed.tenantID]++
+	}
+	for _, b := range cm.breakers {
+		if b.state(cm.cfg.BreakerThreshold, cm.cfg.BreakerCooldown) == BreakerOpen {
+			stats.OpenCircuits++
+		}
+	}
+
+	return stats
+}
+
+// CloseAll stops the janitor, retires every adapter and waits until all of
+// them, including those dropped earlier, have drained and been closed.
+// Acquire fails from then on.
+func (cm *Manager) CloseAll() error {
+	cm.mu.Lock()
+	cm.closed = true
+	stopJanitor := cm.stopJanitor
+	cm.stopJanitor = nil
+	cm.mu.Unlock()
+
+	// The janitor takes the lock itself, so it is stopped without holding it.
+	if stopJanitor != nil {
+		stopJanitor()
+	}
+
+	cm.mu.Lock()
+	for id, managed := range cm.adapters {
+		cm.retire(id, managed)
+	}
+	clear(cm.breakers)
+	cm.mu.Unlock()
+
+	cm.retiring.Wait()
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	return cm.closeErr
+}
+
+func openAdapter(cfg Config, files FilePolicy) (adapters.Adapter, error) {
+	if err := files.Check(cfg.Dialect, cfg.DSN); err != nil {
+		return nil, err
+	}
+
 	switch cfg.Dialect {
 	case PostgreSQL:
-		adapter, err = postgres.NewPostgresAdapter(cfg.DSN)
+		return postgres.NewPostgresAdapter(cfg.DSN, cfg.ReadOnly)
+	case MySQL:
+		return mysql.NewMySQLAdapter(cfg.DSN, cfg.ReadOnly)
+	case SQLite:
+		return sqlite.NewSQLiteAdapter(cfg.DSN, cfg.ReadOnly)
+	case DuckDB:
+		return duckdb.NewDuckDBAdapter(cfg.DSN, cfg.ReadOnly)
 
 	default:
-		return nil, errors.InvalidDialectError{DialectInput: cfg.DSN, ValidDialects: GetDialects()}
+		return nil, errors.InvalidDialectError{DialectInput: string(cfg.Dialect), ValidDialects: GetDialects()}
 	}
+}
 
+// openPinged opens an adapter for cfg and makes sure its database can be
+// reached before it is pooled.
+func openPinged(ctx context.Context, cfg Config, files FilePolicy) (adapters.Adapter, error) {
+	adapter, err := openAdapter(cfg, files)
 	if err != nil {
 		return nil, err
 	}
 
-	db := adapter.GetDB()
+	applyPoolSettings(adapter, cfg.Pool)
 
-	db.SetMaxOpenConns(5)
-	db.SetMaxIdleConns(2)
-	db.SetConnMaxIdleTime(5 * time.Minute)
-	db.SetConnMaxLifetime(1 * time.Hour)
+	pingCtx, cancel := context.WithTimeout(ctx, openTimeout)
+	defer cancel()
+
+	if err := adapter.HealthCheck(pingCtx); err != nil {
+		_ = closeAdapter(cfg.ID, adapter)
+		return nil, err
+	}
 
-	cm.adapters[key] = adapter
 	return adapter, nil
 }
 
-func (cm *Manager) CloseAll() error {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
-	for _, adapter := range cm.adapters {
-		err := adapter.Close()
-		if err != nil {
-			return err
-		}
+func closeAdapter(connectionID string, adapter adapters.Adapter) error {
+	err := adapter.Close()
+	if err != nil {
+		log.Printf("Failed to close adapter of connection %s: %v", connectionID, err)
 	}
-	return nil
+	return err
+}
+
+func checkOnce(ctx context.Context, cfg Config, files FilePolicy) error {
+	adapter, err := openAdapter(cfg, files)
+	if err != nil {
+		return err
+	}
+	defer func(adapter adapters.Adapter) {
+		if err := adapter.Close(); err != nil {
+			log.Printf("Failed to close health check adapter of connection %s: %v", cfg.ID, err)
+		}
+	}(adapter)
+
+	applyPoolSettings(adapter, PoolSettings{MaxOpenConns: 1})
+	return adapter.HealthCheck(ctx)
+}
+
+func applyPoolSettings(adapter adapters.Adapter, pool PoolSettings) {
+	db := adapter.GetDB()
+
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
 }
@@ -0,0 +1,282 @@
+package connection
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/errors"
+)
+
+func testConfig(id string) Config {
+	return Config{ID: id, TenantID: "tenant", Dialect: SQLite, DSN: ":memory:", Pool: PoolSettings{MaxOpenConns: 4}}
+}
+
+func queryOne(t *testing.T, cm *Manager, cfg Config) error {
+	t.Helper()
+
+	adapter, release, err := cm.Acquire(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	rows, err := adapter.RunQuery(context.Background(), "SELECT 1")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	return rows.Close()
+}
+
+func TestInvalidateDrainsInFlightQueries(t *testing.T) {
+	cm := NewConnectionManager(ManagerConfig{})
+	cfg := testConfig("conn")
+
+	old, release, err := cm.Acquire(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	rows, err := old.RunQuery(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("RunQuery: %v", err)
+	}
+
+	cm.Invalidate("conn")
+
+	fresh, releaseFresh, err := cm.Acquire(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Acquire after Invalidate: %v", err)
+	}
+	if fresh == old {
+		t.Fatal("Acquire after Invalidate returned the invalidated adapter")
+	}
+	releaseFresh()
+
+	// The in-flight query keeps working on the old adapter.
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("in-flight rows failed after Invalidate: %v", err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatalf("rows.Close: %v", err)
+	}
+	if err := old.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("old adapter closed before release: %v", err)
+	}
+
+	release()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for old.HealthCheck(context.Background()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("old adapter was not closed after its last release")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := cm.CloseAll(); err != nil {
+		t.Fatalf("CloseAll: %v", err)
+	}
+}
+
+func TestConcurrentQueriesDuringInvalidation(t *testing.T) {
+	cm := NewConnectionManager(ManagerConfig{})
+	cfg := testConfig("conn")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if err := queryOne(t, cm, cfg); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	invalidations := 0
+	for ctx.Err() == nil {
+		cm.Invalidate("conn")
+		invalidations++
+		time.Sleep(time.Millisecond)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("query failed during invalidation: %v", err)
+	}
+
+	if err := cm.CloseAll(); err != nil {
+		t.Fatalf("CloseAll: %v", err)
+	}
+
+	stats := cm.Stats()
+	if stats.RetiringPools != 0 {
+		t.Errorf("RetiringPools = %d after CloseAll, want 0", stats.RetiringPools)
+	}
+	if stats.Opened < 2 {
+		t.Errorf("Opened = %d after %d invalidations, want fresh adapters to be opened", stats.Opened, invalidations)
+	}
+}
+
+func TestAcquireFailuresOpenCircuit(t *testing.T) {
+	dataDir := t.TempDir()
+	cm := NewConnectionManager(ManagerConfig{
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+		Files:            FilePolicy{DataDir: dataDir},
+	})
+
+	// A read-only SQLite file that does not exist fails when dialed, not
+	// when the adapter is created.
+	cfg := testConfig("missing")
+	cfg.DSN = "file:" + filepath.Join(dataDir, "missing.db") + "?mode=ro"
+
+	for i := range 2 {
+		_, _, err := cm.Acquire(context.Background(), cfg)
+		if err == nil {
+			t.Fatalf("Acquire %d of a missing database succeeded", i)
+		}
+		if _, open := err.(errors.CircuitOpenError); open {
+			t.Fatalf("Acquire %d failed fast before the threshold: %v", i, err)
+		}
+	}
+
+	if state, failures := cm.BreakerStatus("missing"); state != BreakerOpen || failures != 2 {
+		t.Fatalf("BreakerStatus = %s, %d; want open, 2", state, failures)
+	}
+
+	_, _, err := cm.Acquire(context.Background(), cfg)
+	if _, open := err.(errors.CircuitOpenError); !open {
+		t.Fatalf("Acquire with an open circuit = %v, want CircuitOpenError", err)
+	}
+	if stats := cm.Stats(); stats.Opened != 0 || stats.OpenPools != 0 {
+		t.Fatalf("failed opens were pooled: %+v", stats)
+	}
+
+	// A cancelled caller does not count against the database.
+	cm.Invalidate("missing")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := cm.Acquire(ctx, cfg); err == nil {
+		t.Fatal("Acquire with a cancelled context succeeded")
+	}
+	if _, failures := cm.BreakerStatus("missing"); failures != 0 {
+		t.Fatalf("cancelled Acquire was recorded as %d failures", failures)
+	}
+}
+
+func TestConcurrentFirstAcquireSharesOnePool(t *testing.T) {
+	cm := NewConnectionManager(ManagerConfig{})
+	cfg := testConfig("conn")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := map[any]bool{}
+
+	for range 8 {
+		wg.Go(func() {
+			adapter, release, err := cm.Acquire(context.Background(), cfg)
+			if err != nil {
+				t.Errorf("Acquire: %v", err)
+				return
+			}
+			defer release()
+
+			mu.Lock()
+			seen[adapter] = true
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if len(seen) != 1 {
+		t.Fatalf("concurrent Acquire handed out %d adapters, want 1", len(seen))
+	}
+	if stats := cm.Stats(); stats.Opened != 1 || stats.OpenPools != 1 {
+		t.Fatalf("Opened = %d, OpenPools = %d; want 1, 1", stats.Opened, stats.OpenPools)
+	}
+
+	if err := cm.CloseAll(); err != nil {
+		t.Fatalf("CloseAll: %v", err)
+	}
+}
+
+func TestCloseAllDrainsAdaptersInUse(t *testing.T) {
+	cm := NewConnectionManager(ManagerConfig{IdleTimeout: time.Hour})
+	cm.StartJanitor(context.Background())
+
+	busy, release, err := cm.Acquire(context.Background(), testConfig("busy"))
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	idle, releaseIdle, err := cm.Acquire(context.Background(), testConfig("idle"))
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	releaseIdle()
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- cm.CloseAll()
+	}()
+
+	// The idle adapter closes right away; the busy one stays usable until
+	// it is released.
+	deadline := time.Now().Add(5 * time.Second)
+	for idle.HealthCheck(context.Background()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("idle adapter was not closed by CloseAll")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := busy.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("adapter closed while in use: %v", err)
+	}
+	select {
+	case err := <-closed:
+		t.Fatalf("CloseAll returned (%v) before the adapter in use was released", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, _, err := cm.Acquire(context.Background(), testConfig("late")); err == nil {
+		t.Fatal("Acquire succeeded after CloseAll")
+	}
+
+	release()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("CloseAll: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CloseAll did not return after the last release")
+	}
+
+	if err := busy.HealthCheck(context.Background()); err == nil {
+		t.Fatal("adapter still open after CloseAll")
+	}
+	if stats := cm.Stats(); stats.OpenPools != 0 || stats.RetiringPools != 0 {
+		t.Fatalf("OpenPools = %d, RetiringPools = %d after CloseAll; want 0, 0", stats.OpenPools, stats.RetiringPools)
+	}
+}
@@ -4,17 +4,18 @@ type Dialect string
 
 const (
 	PostgreSQL Dialect = "postgresql"
+	MySQL      Dialect = "mysql"
 	SQLite     Dialect = "sqlite"
 	DuckDB     Dialect = "duckdb"
 )
 
 func GetDialects() []string {
-	return []string{"postgresql", "sqlite", "duckdb"}
+	return []string{"postgresql", "mysql", "sqlite", "duckdb"}
 }
 
 func IsValidDialect(s string) bool {
 	switch Dialect(s){
-	case PostgreSQL, SQLite, DuckDB:
+	case PostgreSQL, MySQL, SQLite, DuckDB:
 		return true
 	default:
 		return false
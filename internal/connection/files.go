@@ -0,0 +1,147 @@
+package connection
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const DefaultDataDir = "./data"
+
+// uriScheme matches URI schemes; single letters are left alone since they
+// are Windows drive letters.
+var uriScheme = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*$`)
+
+// maxSymlinks bounds how many dangling symlinks resolvePath follows.
+const maxSymlinks = 40
+
+// FilePolicy confines the files embedded databases may open. Without it a
+// tenant could register a connection pointing at any file on the server,
+// including the control-plane database holding every tenant's credentials.
+type FilePolicy struct {
+	// DataDir is the only directory database files may live in. When it is
+	// empty only in-memory databases are allowed.
+	DataDir string
+	// Deny lists files that may never be opened, even inside DataDir. Their
+	// -wal, -shm and -journal companions are denied as well.
+	Deny []string
+}
+
+// Check rejects dsn if it opens a database file outside the data directory
+// or one of the denied files. Server dialects are not affected.
+func (p FilePolicy) Check(dialect Dialect, dsn string) error {
+	var path string
+	var memory bool
+
+	switch dialect {
+	case SQLite:
+		path, memory = sqlitePath(dsn)
+	case DuckDB:
+		var err error
+		if path, memory, err = duckdbPath(dsn); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	if memory {
+		return nil
+	}
+
+	if p.DataDir == "" {
+		return fmt.Errorf("%s databases must be in-memory: no data directory is configured", dialect)
+	}
+
+	resolved := resolvePath(path)
+	rel, err := filepath.Rel(resolvePath(p.DataDir), resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s database files must be inside the data directory", dialect)
+	}
+
+	for _, denied := range p.Deny {
+		denied = resolvePath(denied)
+		if resolved == denied || strings.HasPrefix(resolved, denied+"-") {
+			return fmt.Errorf("%s database file is reserved", dialect)
+		}
+	}
+
+	return nil
+}
+
+// sqlitePath returns the file a SQLite DSN opens, following the rules of
+// go-sqlite3: file: URIs are percent-decoded, anything else up to the first
+// "?" is used as is.
+func sqlitePath(dsn string) (string, bool) {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	if params, err := url.ParseQuery(rawQuery); err == nil && params.Get("mode") == "memory" {
+		return "", true
+	}
+
+	if rest, ok := strings.CutPrefix(path, "file:"); ok {
+		if strings.HasPrefix(rest, "//") {
+			// file://host/path, where the host must be empty or localhost.
+			rest = strings.TrimPrefix(rest, "//")
+			_, rest, _ = strings.Cut(rest, "/")
+			rest = "/" + rest
+		}
+		unescaped, err := url.PathUnescape(rest)
+		if err != nil {
+			// Undecodable paths are checked verbatim and fail to open anyway.
+			unescaped = rest
+		}
+		path = unescaped
+	}
+
+	return path, path == "" || path == ":memory:"
+}
+
+// duckdbPath returns the file a DuckDB DSN opens. Remote databases such as
+// md: or s3:// paths are rejected outright.
+func duckdbPath(dsn string) (string, bool, error) {
+	path, _, _ := strings.Cut(dsn, "?")
+	if path == "" || strings.HasPrefix(path, ":memory:") {
+		return "", true, nil
+	}
+
+	if scheme, _, ok := strings.Cut(path, ":"); ok && uriScheme.MatchString(scheme) && len(scheme) > 1 {
+		return "", false, fmt.Errorf("duckdb database must be a local file")
+	}
+
+	return path, false, nil
+}
+
+// resolvePath makes path absolute and resolves symlinks in it. Dangling
+// symlinks are followed to their target, which SQLite would create.
+func resolvePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+
+	for range maxSymlinks {
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			return resolved
+		}
+
+		dir := filepath.Dir(abs)
+		if resolvedDir, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolvedDir
+		}
+		abs = filepath.Join(dir, filepath.Base(abs))
+
+		target, err := os.Readlink(abs)
+		if err != nil {
+			return abs
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		abs = target
+	}
+
+	return abs
+}
@@ -0,0 +1,90 @@
+package connection
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilePolicySQLite(t *testing.T) {
+	dataDir := t.TempDir()
+	outside := t.TempDir()
+	controlDB := filepath.Join(dataDir, "pinoql.sqlite")
+
+	link := filepath.Join(dataDir, "link.db")
+	if err := os.Symlink(filepath.Join(outside, "secret.db"), link); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	policy := FilePolicy{DataDir: dataDir, Deny: []string{controlDB}}
+
+	tests := []struct {
+		name    string
+		dsn     string
+		wantErr bool
+	}{
+		{"memory", ":memory:", false},
+		{"memory uri", "file::memory:?cache=shared", false},
+		{"memory mode", "file:whatever?mode=memory", false},
+		{"inside data dir", filepath.Join(dataDir, "tenant.db"), false},
+		{"inside data dir uri", "file:" + filepath.Join(dataDir, "tenant.db") + "?mode=ro", false},
+		{"outside data dir", filepath.Join(outside, "tenant.db"), true},
+		{"dot dot escape", filepath.Join(dataDir, "..", filepath.Base(outside), "x.db"), true},
+		{"control-plane database", controlDB, true},
+		{"control-plane wal", controlDB + "-wal", true},
+		{"percent-encoded control-plane database", "file:" + filepath.Join(dataDir, "%70inoql.sqlite"), true},
+		{"triple slash uri outside", "file://" + filepath.Join(outside, "x.db"), true},
+		{"symlink out of data dir", link, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(SQLite, tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%q) error = %v, want error %v", tt.dsn, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilePolicyWithoutDataDir(t *testing.T) {
+	var policy FilePolicy
+
+	if err := policy.Check(SQLite, ":memory:"); err != nil {
+		t.Errorf("in-memory database rejected: %v", err)
+	}
+	if err := policy.Check(SQLite, "/tmp/x.db"); err == nil {
+		t.Error("file database accepted without a data directory")
+	}
+	if err := policy.Check(PostgreSQL, "postgres://localhost/db"); err != nil {
+		t.Errorf("server dialect rejected: %v", err)
+	}
+}
+
+func TestFilePolicyDuckDB(t *testing.T) {
+	dataDir := t.TempDir()
+	policy := FilePolicy{DataDir: dataDir}
+
+	tests := []struct {
+		name    string
+		dsn     string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"memory", ":memory:", false},
+		{"named memory", ":memory:shared?threads=1", false},
+		{"inside data dir", filepath.Join(dataDir, "analytics.duckdb") + "?threads=4", false},
+		{"outside data dir", "/etc/analytics.duckdb", true},
+		{"motherduck", "md:analytics", true},
+		{"s3", "s3://bucket/analytics.duckdb", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(DuckDB, tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%q) error = %v, want error %v", tt.dsn, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/CaioMtho/pinoql-mcp/internal/errors"
+	"github.com/go-sql-driver/mysql"
+)
+
+const testTimeout = 10 * time.Second
+
+var passwordParam = regexp.MustCompile(`(?i)password=('[^']*'|[^\s&;]*)`)
+
+// Test opens dsn read-only through the adapter of dialect, pings it and
+// probes what its credentials can do. Nothing is pooled and the adapter is
+// closed before returning. Errors never contain the DSN or its password.
+func (cm *Manager) Test(ctx context.Context, dialect, dsn string) (*adapters.ProbeResult, error) {
+	if !IsValidDialect(dialect) {
+		return nil, errors.InvalidDialectError{DialectInput: dialect, ValidDialects: GetDialects()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+
+	// In-memory databases leave nothing behind, and DuckDB refuses to open
+	// them read-only.
+	path, _, _ := strings.Cut(dsn, "?")
+	readOnly := path != "" && path != ":memory:"
+
+	adapter, err := openAdapter(Config{Dialect: Dialect(dialect), DSN: dsn, ReadOnly: readOnly}, cm.cfg.Files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection: %v", redact(err, dsn))
+	}
+	defer func(adapter adapters.Adapter) {
+		if err := adapter.Close(); err != nil {
+			log.Printf("Failed to close tested %s connection: %v", dialect, redact(err, dsn))
+		}
+	}(adapter)
+
+	applyPoolSettings(adapter, PoolSettings{MaxOpenConns: 1})
+
+	if err := adapter.HealthCheck(ctx); err != nil {
+		return nil, fmt.Errorf("health check failed: %v", redact(err, dsn))
+	}
+
+	result, err := adapter.Probe(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("probe failed: %v", redact(err, dsn))
+	}
+
+	return result, nil
+}
+
+// redact returns the message of err with the DSN and any password it
+// contains masked, since driver errors sometimes echo their input.
+func redact(err error, dsn string) string {
+	secrets := []string{dsn}
+	if u, parseErr := url.Parse(dsn); parseErr == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			secrets = append(secrets, password, url.QueryEscape(password))
+		}
+	}
+	if cfg, parseErr := mysql.ParseDSN(dsn); parseErr == nil {
+		secrets = append(secrets, cfg.Passwd)
+	}
+	for _, match := range passwordParam.FindAllStringSubmatch(dsn, -1) {
+		secrets = append(secrets, strings.Trim(match[1], "'"))
+	}
+
+	msg := err.Error()
+	for _, secret := range secrets {
+		if secret != "" {
+			msg = strings.ReplaceAll(msg, secret, "***")
+		}
+	}
+
+	return msg
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/signing"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const defaultAdminTokenTTL = 30 * 24 * time.Hour
+
+// Handler lets the super-admin issue and revoke tenant-admin tokens, which
+// are what the connection, token and audit routes accept.
+type Handler struct {
+	repo       *Repository
+	tenantRepo *tenant.Repository
+	signer     *signing.KeyManager
+}
+
+func NewAdminHandler(repo *Repository, tenantRepo *tenant.Repository, signer *signing.KeyManager) *Handler {
+	return &Handler{
+		repo:       repo,
+		tenantRepo: tenantRepo,
+		signer:     signer,
+	}
+}
+
+func (h *Handler) IssueToken(c *gin.Context) {
+	tenantID := c.Param("id")
+
+	var req claims.AdminTokenIssueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.tenantRepo.GetTenantByID(tenantID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ttl := defaultAdminTokenTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	jti := uuid.New().String()
+
+	adminClaims := claims.AdminClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   tenantID,
+			Audience:  jwt.ClaimStrings{claims.AdminAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ID:        jti,
+			Issuer:    "pinoql-mcp",
+		},
+		TenantID: tenantID,
+	}
+
+	signedToken, err := h.signer.Sign(adminClaims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign token"})
+		return
+	}
+
+	newToken := NewAdminToken{
+		TenantID:  tenantID,
+		IssuedAt:  now,
+		ExpiresAt: expiresAt,
+	}
+	if req.Description != "" {
+		newToken.Description = &req.Description
+	}
+
+	if err := h.repo.InsertToken(jti, newToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, claims.JWTIssueResponse{
+		Token:     signedToken,
+		ExpiresAt: expiresAt.Unix(),
+		JTI:       jti,
+	})
+}
+
+func (h *Handler) ListTokens(c *gin.Context) {
+	tokens, err := h.repo.ListTokensByTenant(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+func (h *Handler) RevokeToken(c *gin.Context) {
+	err := h.repo.RevokeToken(c.Param("id"), c.Param("jti"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "token revoked successfully"})
+}
@@ -0,0 +1,24 @@
+package admin
+
+import "time"
+
+type AdminToken struct {
+	JTI         string     `json:"jti" db:"jti"`
+	TenantID    string     `json:"tenant_id" db:"tenant_id"`
+	Description *string    `json:"description,omitempty" db:"description"`
+	IssuedAt    time.Time  `json:"issued_at" db:"issued_at"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	Revoked     bool       `json:"revoked" db:"revoked"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+type NewAdminToken struct {
+	TenantID    string    `json:"tenant_id" db:"tenant_id" validate:"required"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	IssuedAt    time.Time `json:"issued_at" db:"issued_at"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+}
+
+func (t *AdminToken) TableName() string {
+	return "admin_tokens"
+}
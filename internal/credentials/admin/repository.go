@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewAdminRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) InsertToken(jti string, data NewAdminToken) error {
+	query := `
+		INSERT INTO admin_tokens (jti, tenant_id, description, issued_at, expires_at, revoked)
+		VALUES (?, ?, ?, ?, ?, 0)
+	`
+
+	_, err := r.db.Exec(query, jti, data.TenantID, data.Description, data.IssuedAt, data.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert admin token: %w", err)
+	}
+
+	return nil
+}
+
+// IsTokenRevoked reports whether the admin token is unusable. Unlike agent
+// tokens, admin tokens must have been recorded when issued, so an unknown jti
+// is treated as revoked.
+func (r *Repository) IsTokenRevoked(jti string) (bool, error) {
+	var revoked bool
+
+	query := `SELECT revoked FROM admin_tokens WHERE jti = ?`
+
+	err := r.db.Get(&revoked, query, jti)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to check revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+func (r *Repository) RevokeToken(tenantID, jti string) error {
+	query := `
+		UPDATE admin_tokens
+		SET revoked = 1, revoked_at = CURRENT_TIMESTAMP
+		WHERE jti = ? AND tenant_id = ?
+	`
+
+	result, err := r.db.Exec(query, jti, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke admin token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *Repository) ListTokensByTenant(tenantID string) ([]*AdminToken, error) {
+	var tokens []*AdminToken
+
+	query := `
+		SELECT jti, tenant_id, description, issued_at, expires_at, revoked, revoked_at
+		FROM admin_tokens
+		WHERE tenant_id = ?
+		ORDER BY issued_at DESC
+	`
+
+	err := r.db.Select(&tokens, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admin tokens: %w", err)
+	}
+
+	return tokens, nil
+}
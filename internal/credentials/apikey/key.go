@@ -0,0 +1,51 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Keys look like "pq_<prefix>_<secret>". The prefix is stored in clear to
+// find the row and to let users recognise their keys; only a hash of the
+// secret is stored.
+const keyPrefix = "pq"
+
+func generateKey() (prefix, secret, key string, err error) {
+	prefixBytes := make([]byte, 6)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate key prefix: %w", err)
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate key secret: %w", err)
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	secret = base64.RawURLEncoding.EncodeToString(secretBytes)
+	return prefix, secret, keyPrefix + "_" + prefix + "_" + secret, nil
+}
+
+func parseKey(key string) (prefix, secret string, err error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("malformed API key")
+	}
+	return parts[1], parts[2], nil
+}
+
+// hashSecret uses a plain SHA-256: the secret is 256 bits of randomness, so
+// a slow password hash would add latency without adding security.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func secretMatches(secret, storedHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(storedHash)) == 1
+}
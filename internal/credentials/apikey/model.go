@@ -0,0 +1,76 @@
+package apikey
+
+import (
+	"slices"
+	"time"
+)
+
+// Scopes an API key can be granted. ScopeAll grants every scope.
+const (
+	ScopeAll         = "*"
+	ScopeConnections = "connections"
+	ScopeTokens      = "tokens"
+	ScopeAudit       = "audit"
+)
+
+func GetScopes() []string {
+	return []string{ScopeAll, ScopeConnections, ScopeTokens, ScopeAudit}
+}
+
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	TenantID   string     `json:"tenant_id" db:"tenant_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	SecretHash string     `json:"-" db:"secret_hash"`
+	Scopes     string     `json:"-" db:"scopes"` // JSON array string
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	Revoked    bool       `json:"revoked" db:"revoked"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type NewAPIKey struct {
+	Name       string   `json:"name" validate:"required"`
+	Scopes     []string `json:"scopes" validate:"required,min=1"`
+	TTLSeconds int      `json:"ttl_seconds,omitempty" validate:"min=0"`
+}
+
+type APIKeyInfo struct {
+	ID         string     `json:"id"`
+	TenantID   string     `json:"tenant_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Revoked    bool       `json:"revoked"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKey is returned once, on creation. The plaintext key is not
+// stored and cannot be retrieved afterwards.
+type CreatedAPIKey struct {
+	APIKeyInfo
+	Key string `json:"key"`
+}
+
+// VerifiedAPIKey is what a successful verification yields.
+type VerifiedAPIKey struct {
+	ID       string
+	TenantID string
+	Scopes   []string
+}
+
+func (k *VerifiedAPIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, ScopeAll) || slices.Contains(k.Scopes, scope)
+}
+
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+func (k *APIKey) TableName() string {
+	return "api_keys"
+}
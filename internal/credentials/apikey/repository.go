@@ -0,0 +1,209 @@
+package apikey
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) CreateKey(tenantID string, data NewAPIKey) (*CreatedAPIKey, error) {
+	for _, scope := range data.Scopes {
+		if !slices.Contains(GetScopes(), scope) {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+	}
+
+	prefix, secret, key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	scopesJSON, err := json.Marshal(data.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize scopes: %w", err)
+	}
+
+	var expiresAt *time.Time
+	if data.TTLSeconds > 0 {
+		t := time.Now().Add(time.Duration(data.TTLSeconds) * time.Second)
+		expiresAt = &t
+	}
+
+	id := uuid.New().String()
+
+	query := `
+		INSERT INTO api_keys (id, tenant_id, name, prefix, secret_hash, scopes, expires_at, revoked)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 0)
+	`
+
+	_, err = r.db.Exec(query, id, tenantID, data.Name, prefix, hashSecret(secret), string(scopesJSON), expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert API key: %w", err)
+	}
+
+	info, err := r.GetKeyByID(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreatedAPIKey{APIKeyInfo: *info, Key: key}, nil
+}
+
+func (r *Repository) GetKeyByID(tenantID, id string) (*APIKeyInfo, error) {
+	var key APIKey
+
+	query := `
+		SELECT id, tenant_id, name, prefix, secret_hash, scopes, expires_at, last_used_at, revoked, revoked_at, created_at
+		FROM api_keys
+		WHERE id = ? AND tenant_id = ?
+	`
+
+	err := r.db.Get(&key, query, id, tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("API key not found")
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key.info()
+}
+
+func (r *Repository) ListKeysByTenant(tenantID string) ([]*APIKeyInfo, error) {
+	var keys []*APIKey
+
+	query := `
+		SELECT id, tenant_id, name, prefix, secret_hash, scopes, expires_at, last_used_at, revoked, revoked_at, created_at
+		FROM api_keys
+		WHERE tenant_id = ?
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.Select(&keys, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	infos := make([]*APIKeyInfo, 0, len(keys))
+	for _, key := range keys {
+		info, err := key.info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func (r *Repository) RevokeKey(tenantID, id string) error {
+	query := `
+		UPDATE api_keys
+		SET revoked = 1, revoked_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND tenant_id = ? AND revoked = 0
+	`
+
+	result, err := r.db.Exec(query, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// VerifyKey checks a presented key and returns its tenant and scopes. Every
+// failure is reported as ErrInvalidAPIKey so callers cannot tell an unknown
+// prefix from a wrong secret.
+func (r *Repository) VerifyKey(presented string) (*VerifiedAPIKey, error) {
+	prefix, secret, err := parseKey(presented)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var key APIKey
+
+	query := `
+		SELECT k.id, k.tenant_id, k.name, k.prefix, k.secret_hash, k.scopes, k.expires_at, k.last_used_at, k.revoked, k.revoked_at, k.created_at
+		FROM api_keys k
+		JOIN tenants t ON t.id = k.tenant_id
+		WHERE k.prefix = ? AND t.is_active = 1
+	`
+
+	err = r.db.Get(&key, query, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Hash anyway so a miss takes as long as a mismatch.
+			secretMatches(secret, "")
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	if !secretMatches(secret, key.SecretHash) || key.Revoked || key.IsExpired() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	scopes, err := deserializeScopes(key.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _ = r.db.Exec(`UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`, key.ID)
+
+	return &VerifiedAPIKey{
+		ID:       key.ID,
+		TenantID: key.TenantID,
+		Scopes:   scopes,
+	}, nil
+}
+
+func (k *APIKey) info() (*APIKeyInfo, error) {
+	scopes, err := deserializeScopes(k.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIKeyInfo{
+		ID:         k.ID,
+		TenantID:   k.TenantID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		Revoked:    k.Revoked,
+		CreatedAt:  k.CreatedAt,
+	}, nil
+}
+
+func deserializeScopes(data string) ([]string, error) {
+	var scopes []string
+	if err := json.Unmarshal([]byte(data), &scopes); err != nil {
+		return nil, fmt.Errorf("failed to deserialize scopes: %w", err)
+	}
+	return scopes, nil
+}
@@ -2,6 +2,12 @@ package audit
 
 import "time"
 
+const (
+	ActionQuery   = "query"
+	ActionSchema  = "schema"
+	ActionConnect = "connect"
+)
+
 type ConnectionAuditLog struct {
 	ID              int64     `json:"id" db:"id"`
 	TenantID        string    `json:"tenant_id" db:"tenant_id"`
@@ -11,19 +17,19 @@ type ConnectionAuditLog struct {
 	Success         bool      `json:"success" db:"success"`
 	ErrorMessage    *string   `json:"error_message,omitempty" db:"error_message"`
 	ExecutionTimeMs *int      `json:"execution_time_ms,omitempty" db:"execution_time_ms"`
-	RowsAffected    *int      `json:"rows_affected,omitempty" db:"rows_affected"`
+	RowsReturned    *int      `json:"rows_returned,omitempty" db:"rows_returned"`
 	Timestamp       time.Time `json:"timestamp" db:"timestamp"`
 }
 
 type NewConnectionAuditLog struct {
 	TenantID        string  `json:"tenant_id" db:"tenant_id" validate:"required"`
-	ConnectionID    string  `json:"connection_id" db:"connection_id" validate:"required"`
+	ConnectionID    string  `json:"connection_id" db:"connection_id"`
 	Action          string  `json:"action" db:"action" validate:"required,oneof=query schema connect"`
 	QueryHash       *string `json:"query_hash,omitempty" db:"query_hash"`
 	Success         bool    `json:"success" db:"success"`
 	ErrorMessage    *string `json:"error_message,omitempty" db:"error_message"`
 	ExecutionTimeMs *int    `json:"execution_time_ms,omitempty" db:"execution_time_ms"`
-	RowsAffected    *int    `json:"rows_affected,omitempty" db:"rows_affected"`
+	RowsReturned    *int    `json:"rows_returned,omitempty" db:"rows_returned"`
 }
 
 type AuditLogQuery struct {
@@ -38,13 +44,13 @@ type AuditLogQuery struct {
 }
 
 type AuditLogStats struct {
-	TenantID          string  `json:"tenant_id"`
-	ConnectionID      string  `json:"connection_id"`
-	TotalQueries      int64   `json:"total_queries"`
-	SuccessfulQueries int64   `json:"successful_queries"`
-	FailedQueries     int64   `json:"failed_queries"`
-	AvgExecutionMs    float64 `json:"avg_execution_ms"`
-	TotalRowsAffected int64   `json:"total_rows_affected"`
+	TenantID          string  `json:"tenant_id" db:"tenant_id"`
+	ConnectionID      string  `json:"connection_id" db:"connection_id"`
+	TotalQueries      int64   `json:"total_queries" db:"total_queries"`
+	SuccessfulQueries int64   `json:"successful_queries" db:"successful_queries"`
+	FailedQueries     int64   `json:"failed_queries" db:"failed_queries"`
+	AvgExecutionMs    float64 `json:"avg_execution_ms" db:"avg_execution_ms"`
+	TotalRowsReturned int64   `json:"total_rows_returned" db:"total_rows_returned"`
 }
 
 func (ConnectionAuditLog) TableName() string {
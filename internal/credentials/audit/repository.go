@@ -1,6 +1,7 @@
 package audit
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -19,11 +20,11 @@ func (r *Repository) InsertLog(data NewConnectionAuditLog) error {
 	query := `
 		INSERT INTO connection_audit_log (
 			tenant_id, connection_id, action, query_hash, success, 
-			error_message, execution_time_ms, rows_affected
+			error_message, execution_time_ms, rows_returned
 		)
 		VALUES (
 			:tenant_id, :connection_id, :action, :query_hash, :success,
-			:error_message, :execution_time_ms, :rows_affected
+			:error_message, :execution_time_ms, :rows_returned
 		)
 	`
 
@@ -35,6 +36,45 @@ func (r *Repository) InsertLog(data NewConnectionAuditLog) error {
 	return nil
 }
 
+// InsertLogs writes a batch of logs in a single transaction. A log that
+// cannot be inserted is skipped and reported in the returned error without
+// losing the rest of the batch.
+func (r *Repository) InsertLogs(logs []NewConnectionAuditLog) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin audit transaction: %w", err)
+	}
+
+	stmt, err := tx.PrepareNamed(`
+		INSERT INTO connection_audit_log (
+			tenant_id, connection_id, action, query_hash, success,
+			error_message, execution_time_ms, rows_returned
+		)
+		VALUES (
+			:tenant_id, :connection_id, :action, :query_hash, :success,
+			:error_message, :execution_time_ms, :rows_returned
+		)
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to prepare audit insert: %w", err)
+	}
+
+	var errs []error
+	for _, data := range logs {
+		if _, err := stmt.Exec(data); err != nil {
+			errs = append(errs, fmt.Errorf("failed to insert audit log for connection %s: %w", data.ConnectionID, err))
+		}
+	}
+	_ = stmt.Close()
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit logs: %w", err)
+	}
+
+	return errors.Join(errs...)
+}
+
 func (r *Repository) ListLogs(filter AuditLogQuery) ([]*ConnectionAuditLog, error) {
 	var logs []*ConnectionAuditLog
 
@@ -82,7 +122,7 @@ func (r *Repository) ListLogs(filter AuditLogQuery) ([]*ConnectionAuditLog, erro
 	query := fmt.Sprintf(`
 		SELECT 
 			id, tenant_id, connection_id, action, query_hash, 
-			success, error_message, execution_time_ms, rows_affected, timestamp
+			success, error_message, execution_time_ms, rows_returned, timestamp
 		FROM connection_audit_log
 		%s
 		ORDER BY timestamp DESC
@@ -122,7 +162,7 @@ func (r *Repository) GetStatsByConnection(tenantID, connectionID string) (*Audit
 			SUM(CASE WHEN success = 1 THEN 1 ELSE 0 END) as successful_queries,
 			SUM(CASE WHEN success = 0 THEN 1 ELSE 0 END) as failed_queries,
 			AVG(COALESCE(execution_time_ms, 0)) as avg_execution_ms,
-			SUM(COALESCE(rows_affected, 0)) as total_rows_affected
+			SUM(COALESCE(rows_returned, 0)) as total_rows_returned
 		FROM connection_audit_log
 		WHERE tenant_id = ? AND connection_id = ?
 		GROUP BY tenant_id, connection_id
@@ -147,7 +187,7 @@ func (r *Repository) GetStatsByTenant(tenantID string) ([]*AuditLogStats, error)
 			SUM(CASE WHEN success = 1 THEN 1 ELSE 0 END) as successful_queries,
 			SUM(CASE WHEN success = 0 THEN 1 ELSE 0 END) as failed_queries,
 			AVG(COALESCE(execution_time_ms, 0)) as avg_execution_ms,
-			SUM(COALESCE(rows_affected, 0)) as total_rows_affected
+			SUM(COALESCE(rows_returned, 0)) as total_rows_returned
 		FROM connection_audit_log
 		WHERE tenant_id = ?
 		GROUP BY tenant_id, connection_id
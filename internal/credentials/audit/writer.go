@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultWriterBufferSize = 1024
+	writerBatchSize         = 100
+	writerFlushInterval     = time.Second
+)
+
+// Writer records audit logs in the background so that auditing never adds
+// latency to the calls being audited. Logs are batched and written either
+// when a batch fills up or every writerFlushInterval. When the buffer is full
+// new logs are dropped and counted rather than blocking the caller.
+type Writer struct {
+	repo    *Repository
+	entries chan NewConnectionAuditLog
+	done    chan struct{}
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewWriter(repo *Repository, bufferSize int) *Writer {
+	if bufferSize <= 0 {
+		bufferSize = DefaultWriterBufferSize
+	}
+
+	w := &Writer{
+		repo:    repo,
+		entries: make(chan NewConnectionAuditLog, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+
+	return w
+}
+
+func (w *Writer) Log(entry NewConnectionAuditLog) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(1)
+		return
+	}
+
+	select {
+	case w.entries <- entry:
+	default:
+		if w.dropped.Add(1)%100 == 1 {
+			log.Printf("Warning: audit buffer full, dropped %d audit logs so far", w.dropped.Load())
+		}
+	}
+}
+
+// Dropped returns how many logs were discarded because the buffer was full or
+// the writer was closed.
+func (w *Writer) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close stops accepting logs and waits until everything buffered has been
+// written, or until ctx is done.
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(writerFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]NewConnectionAuditLog, 0, writerBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.repo.InsertLogs(batch); err != nil {
+			log.Printf("Failed to write audit logs: %v", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= writerBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
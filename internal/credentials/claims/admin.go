@@ -0,0 +1,30 @@
+package claims
+
+import (
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AdminAudience marks tokens that grant access to the management API. Agent
+// tokens never carry it, so one kind of token cannot be used in place of the
+// other.
+const AdminAudience = "pinoql-admin"
+
+type AdminClaims struct {
+	jwt.RegisteredClaims
+	TenantID string `json:"tenant_id"`
+}
+
+func (c *AdminClaims) IsAdminToken() bool {
+	return slices.Contains(c.Audience, AdminAudience)
+}
+
+func (c *PinoQLClaims) IsAdminToken() bool {
+	return slices.Contains(c.Audience, AdminAudience)
+}
+
+type AdminTokenIssueRequest struct {
+	Description string `json:"description"`
+	TTLSeconds  int    `json:"ttl_seconds" validate:"min=60,max=2592000"`
+}
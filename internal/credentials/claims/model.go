@@ -18,6 +18,9 @@ type ConnectionPermissions struct {
 	DDL        bool     `json:"ddl"`
 	MaxRows    int      `json:"max_rows"`
 	AllowedOps []string `json:"allowed_ops"`
+	// QueryTimeoutSeconds caps how long a single query may run; 0 defers to
+	// the connection's or the server's default.
+	QueryTimeoutSeconds int `json:"query_timeout_seconds,omitempty"`
 }
 
 func DefaultReadOnlyPermissions() ConnectionPermissions {
@@ -95,6 +98,10 @@ func (c *PinoQLClaims) GetMaxRows() int {
 	return c.Permissions.MaxRows
 }
 
+func (c *PinoQLClaims) GetQueryTimeoutSeconds() int {
+	return c.Permissions.QueryTimeoutSeconds
+}
+
 type JWTIssueRequest struct {
 	TenantID      string                `json:"tenant_id" validate:"required"`
 	ConnectionIDs []string              `json:"connection_ids" validate:"required,min=1"`
@@ -1,17 +1,58 @@
 package connection_data
 
 import (
+	"context"
 	"net/http"
+	"time"
 
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
 	"github.com/gin-gonic/gin"
 )
 
+// Tester opens a DSN without pooling or persisting it, checks that it is
+// reachable and probes what its credentials can do.
+type Tester func(ctx context.Context, dialect, dsn string) (*adapters.ProbeResult, error)
+
 type ConnectionHandler struct {
 	repo *Repository
+	test Tester
+}
+
+func NewConnectionHandler(repo *Repository, test Tester) *ConnectionHandler {
+	return &ConnectionHandler{repo: repo, test: test}
 }
 
-func NewConnectionHandler(repo *Repository) *ConnectionHandler {
-	return &ConnectionHandler{repo: repo}
+// TestConnection reports whether a DSN is reachable, the server version and
+// whether its user can write. The DSN is neither stored nor logged.
+func (h *ConnectionHandler) TestConnection(c *gin.Context) {
+	var req TestConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Dialect == "" || req.DSN == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dialect and dsn are required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.runTest(c.Request.Context(), req.Dialect, req.DSN))
+}
+
+func (h *ConnectionHandler) runTest(ctx context.Context, dialect, dsn string) *ConnectionTestResult {
+	start := time.Now()
+	probe, err := h.test(ctx, dialect, dsn)
+
+	result := &ConnectionTestResult{
+		Success:     err == nil,
+		LatencyMs:   time.Since(start).Milliseconds(),
+		ProbeResult: probe,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
 }
 
 func (h *ConnectionHandler) CreateConnection(c *gin.Context) {
@@ -27,8 +68,20 @@ func (h *ConnectionHandler) CreateConnection(c *gin.Context) {
 		return
 	}
 
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	req.TenantID = tenantID
 
+	if c.Query("validate") == "true" {
+		if test := h.runTest(c.Request.Context(), req.Dialect, req.DSN); !test.Success {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "connection test failed", "test": test})
+			return
+		}
+	}
+
 	result, err := h.repo.InsertConnection(req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -87,6 +140,33 @@ func (h *ConnectionHandler) UpdateConnection(c *gin.Context) {
 		return
 	}
 
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("validate") == "true" {
+		// Fields left out of the update are tested with their stored values.
+		current, err := h.repo.GetConnectionWithDSN(tenantID, connectionID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		dialect, dsn := current.Dialect, current.DSN
+		if req.Dialect != nil {
+			dialect = *req.Dialect
+		}
+		if req.DSN != nil {
+			dsn = *req.DSN
+		}
+
+		if test := h.runTest(c.Request.Context(), dialect, dsn); !test.Success {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "connection test failed", "test": test})
+			return
+		}
+	}
+
 	err := h.repo.UpdateConnection(tenantID, connectionID, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
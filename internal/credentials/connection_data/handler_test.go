@@ -0,0 +1,137 @@
+package connection_data_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/CaioMtho/pinoql-mcp/internal/crypto"
+	"github.com/CaioMtho/pinoql-mcp/internal/migrate"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	masterKey := make([]byte, 32)
+	_, _ = rand.Read(masterKey)
+	cm, err := crypto.NewCryptoManager(masterKey)
+	if err != nil {
+		t.Fatalf("NewCryptoManager: %v", err)
+	}
+
+	migrator, err := migrate.NewMigrator(db, cm)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO tenants (id, name, is_active) VALUES ('t1', 't1', 1)`); err != nil {
+		t.Fatalf("insert tenant: %v", err)
+	}
+
+	handler := connection_data.NewConnectionHandler(connection_data.NewConnectionDataRepository(db, cm), nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("tenant_id", "t1")
+	})
+	r.POST("/connections", handler.CreateConnection)
+	r.PUT("/connections/:id", handler.UpdateConnection)
+	return r
+}
+
+func send(t *testing.T, r *gin.Engine, method, path string, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestConnectionSettingsBounds(t *testing.T) {
+	r := newTestRouter(t)
+
+	created := 0
+	base := func(name string, value any) map[string]any {
+		created++
+		body := map[string]any{"name": fmt.Sprintf("c%d", created), "dialect": "sqlite", "dsn": ":memory:"}
+		if name != "" {
+			body[name] = value
+		}
+		return body
+	}
+
+	w := send(t, r, http.MethodPost, "/connections", base("max_connections", 5))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create with valid settings: %d %s", w.Code, w.Body)
+	}
+	var conn struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &conn); err != nil || conn.ID == "" {
+		t.Fatalf("create response %s: %v", w.Body, err)
+	}
+
+	if w := send(t, r, http.MethodPost, "/connections", base("", nil)); w.Code != http.StatusCreated {
+		t.Fatalf("create with default settings: %d %s", w.Code, w.Body)
+	}
+
+	invalid := []struct {
+		field string
+		value int
+	}{
+		{"max_connections", -1},
+		{"max_connections", 101},
+		{"query_timeout_seconds", -5},
+		{"query_timeout_seconds", 3601},
+		{"max_idle_connections", -1},
+		{"max_idle_connections", 1000},
+		{"conn_max_idle_time_seconds", -1},
+		{"conn_max_idle_time_seconds", 86401},
+		{"conn_max_lifetime_seconds", -1},
+		{"conn_max_lifetime_seconds", 1 << 40},
+	}
+
+	for _, tt := range invalid {
+		if w := send(t, r, http.MethodPost, "/connections", base(tt.field, tt.value)); w.Code != http.StatusBadRequest {
+			t.Errorf("create with %s=%d: status %d, want 400", tt.field, tt.value, w.Code)
+		}
+		if w := send(t, r, http.MethodPut, "/connections/"+conn.ID, map[string]any{tt.field: tt.value}); w.Code != http.StatusBadRequest {
+			t.Errorf("update with %s=%d: status %d, want 400", tt.field, tt.value, w.Code)
+		}
+	}
+
+	// Updates cannot fall back to the default, so 0 connections is invalid.
+	if w := send(t, r, http.MethodPut, "/connections/"+conn.ID, map[string]any{"max_connections": 0}); w.Code != http.StatusBadRequest {
+		t.Errorf("update with max_connections=0: status %d, want 400", w.Code)
+	}
+
+	if w := send(t, r, http.MethodPut, "/connections/"+conn.ID, map[string]any{"max_idle_connections": 3, "query_timeout_seconds": 60}); w.Code != http.StatusOK {
+		t.Fatalf("update with valid settings: %d %s", w.Code, w.Body)
+	}
+}
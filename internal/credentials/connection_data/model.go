@@ -1,20 +1,46 @@
 package connection_data
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+)
+
+// Pool defaults, used when a connection is created without explicit
+// settings. They match the column defaults.
+const (
+	DefaultMaxConnections         = 10
+	DefaultMaxIdleConnections     = 2
+	DefaultConnMaxIdleTimeSeconds = 300
+	DefaultConnMaxLifetimeSeconds = 3600
+)
+
+// Upper bounds of the pool and timeout settings a connection may request.
+const (
+	MaxConnectionsLimit    = 100
+	MaxQueryTimeoutSeconds = 3600
+	MaxConnDurationSeconds = 86400
+)
 
 type ConnectionData struct {
-	ID             string    `json:"id" db:"id"`
-	TenantID       string    `json:"tenant_id" db:"tenant_id"`
-	Name           string    `json:"name" db:"name"`
-	Description    *string   `json:"description,omitempty" db:"description"`
-	DSN            string    `json:"dsn" db:"dsn"`
-	Dialect        string    `json:"dialect" db:"dialect"`
-	DEK            string    `json:"dek" db:"dek"`
-	ReadOnly       bool      `json:"readonly" db:"readonly"`
-	MaxConnections int       `json:"max_connections" db:"max_connections"`
-	IsActive       bool      `json:"is_active" db:"is_active"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	ID                 string    `json:"id" db:"id"`
+	TenantID           string    `json:"tenant_id" db:"tenant_id"`
+	Name               string    `json:"name" db:"name"`
+	Description        *string   `json:"description,omitempty" db:"description"`
+	DSN                string    `json:"dsn" db:"dsn"`
+	Dialect            string    `json:"dialect" db:"dialect"`
+	DEK                string    `json:"dek" db:"dek"`
+	DEKKeyID           string    `json:"dek_key_id" db:"dek_key_id"`
+	ReadOnly           bool      `json:"readonly" db:"readonly"`
+	MaxConnections     int       `json:"max_connections" db:"max_connections"`
+	QueryTimeout       int       `json:"query_timeout_seconds" db:"query_timeout_seconds"`
+	MaxIdleConnections int       `json:"max_idle_connections" db:"max_idle_connections"`
+	ConnMaxIdleTime    int       `json:"conn_max_idle_time_seconds" db:"conn_max_idle_time_seconds"`
+	ConnMaxLifetime    int       `json:"conn_max_lifetime_seconds" db:"conn_max_lifetime_seconds"`
+	IsActive           bool      `json:"is_active" db:"is_active"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type NewConnectionData struct {
@@ -22,32 +48,93 @@ type NewConnectionData struct {
 	Name           string  `json:"name" db:"name" validate:"required"`
 	Description    *string `json:"description,omitempty" db:"description"`
 	DSN            string  `json:"dsn" db:"dsn" validate:"required"`
-	Dialect        string  `json:"dialect" db:"dialect" validate:"required,oneof=postgresql mysql sqlite"`
+	Dialect        string  `json:"dialect" db:"dialect" validate:"required,oneof=postgresql mysql sqlite duckdb"`
 	ReadOnly       bool    `json:"readonly" db:"readonly"`
 	MaxConnections int     `json:"max_connections" db:"max_connections" validate:"min=1,max=100"`
+	QueryTimeout   int     `json:"query_timeout_seconds" db:"query_timeout_seconds" validate:"min=0,max=3600"`
+	// Pool settings left unset take the defaults above. Durations of 0
+	// mean connections are never closed for age or idleness.
+	MaxIdleConnections *int `json:"max_idle_connections,omitempty" db:"max_idle_connections" validate:"omitempty,min=0,max=100"`
+	ConnMaxIdleTime    *int `json:"conn_max_idle_time_seconds,omitempty" db:"conn_max_idle_time_seconds" validate:"omitempty,min=0,max=86400"`
+	ConnMaxLifetime    *int `json:"conn_max_lifetime_seconds,omitempty" db:"conn_max_lifetime_seconds" validate:"omitempty,min=0,max=86400"`
 }
 
 type UpdateConnectionData struct {
-	Name           *string `json:"name,omitempty" db:"name"`
-	Description    *string `json:"description,omitempty" db:"description"`
-	DSN            *string `json:"dsn,omitempty" db:"dsn"`
-	Dialect        *string `json:"dialect,omitempty" db:"dialect" validate:"omitempty,oneof=postgresql mysql sqlite"`
-	ReadOnly       *bool   `json:"readonly,omitempty" db:"readonly"`
-	MaxConnections *int    `json:"max_connections,omitempty" db:"max_connections" validate:"omitempty,min=1,max=100"`
-	IsActive       *bool   `json:"is_active,omitempty" db:"is_active"`
+	Name               *string `json:"name,omitempty" db:"name"`
+	Description        *string `json:"description,omitempty" db:"description"`
+	DSN                *string `json:"dsn,omitempty" db:"dsn"`
+	Dialect            *string `json:"dialect,omitempty" db:"dialect" validate:"omitempty,oneof=postgresql mysql sqlite duckdb"`
+	ReadOnly           *bool   `json:"readonly,omitempty" db:"readonly"`
+	MaxConnections     *int    `json:"max_connections,omitempty" db:"max_connections" validate:"omitempty,min=1,max=100"`
+	QueryTimeout       *int    `json:"query_timeout_seconds,omitempty" db:"query_timeout_seconds" validate:"omitempty,min=0,max=3600"`
+	MaxIdleConnections *int    `json:"max_idle_connections,omitempty" db:"max_idle_connections" validate:"omitempty,min=0,max=100"`
+	ConnMaxIdleTime    *int    `json:"conn_max_idle_time_seconds,omitempty" db:"conn_max_idle_time_seconds" validate:"omitempty,min=0,max=86400"`
+	ConnMaxLifetime    *int    `json:"conn_max_lifetime_seconds,omitempty" db:"conn_max_lifetime_seconds" validate:"omitempty,min=0,max=86400"`
+	IsActive           *bool   `json:"is_active,omitempty" db:"is_active"`
 }
 
 type ConnectionDataQuery struct {
-	ID             string    `json:"id" db:"id"`
-	TenantID       string    `json:"tenant_id" db:"tenant_id"`
-	Name           string    `json:"name" db:"name"`
-	Description    *string   `json:"description,omitempty" db:"description"`
-	Dialect        string    `json:"dialect" db:"dialect"`
-	ReadOnly       bool      `json:"readonly" db:"readonly"`
-	MaxConnections int       `json:"max_connections" db:"max_connections"`
-	IsActive       bool      `json:"is_active" db:"is_active"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	ID                 string    `json:"id" db:"id"`
+	TenantID           string    `json:"tenant_id" db:"tenant_id"`
+	Name               string    `json:"name" db:"name"`
+	Description        *string   `json:"description,omitempty" db:"description"`
+	Dialect            string    `json:"dialect" db:"dialect"`
+	ReadOnly           bool      `json:"readonly" db:"readonly"`
+	MaxConnections     int       `json:"max_connections" db:"max_connections"`
+	QueryTimeout       int       `json:"query_timeout_seconds" db:"query_timeout_seconds"`
+	MaxIdleConnections int       `json:"max_idle_connections" db:"max_idle_connections"`
+	ConnMaxIdleTime    int       `json:"conn_max_idle_time_seconds" db:"conn_max_idle_time_seconds"`
+	ConnMaxLifetime    int       `json:"conn_max_lifetime_seconds" db:"conn_max_lifetime_seconds"`
+	IsActive           bool      `json:"is_active" db:"is_active"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type TestConnectionRequest struct {
+	Dialect string `json:"dialect" validate:"required,oneof=postgresql mysql sqlite duckdb"`
+	DSN     string `json:"dsn" validate:"required"`
+}
+
+// ConnectionTestResult is the outcome of opening and probing a DSN. The
+// probe fields are omitted when the connection could not be established.
+type ConnectionTestResult struct {
+	Success   bool   `json:"success"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	*adapters.ProbeResult
+}
+
+// Validate checks the pool and timeout settings. A max_connections of 0
+// means the default.
+func (d NewConnectionData) Validate() error {
+	return validateSettings(&d.MaxConnections, &d.QueryTimeout, d.MaxIdleConnections, d.ConnMaxIdleTime, d.ConnMaxLifetime, 0)
+}
+
+// Validate checks the pool and timeout settings being changed.
+func (u UpdateConnectionData) Validate() error {
+	return validateSettings(u.MaxConnections, u.QueryTimeout, u.MaxIdleConnections, u.ConnMaxIdleTime, u.ConnMaxLifetime, 1)
+}
+
+func validateSettings(maxConns, queryTimeout, maxIdle, maxIdleTime, maxLifetime *int, minConns int) error {
+	checks := []struct {
+		name     string
+		value    *int
+		min, max int
+	}{
+		{"max_connections", maxConns, minConns, MaxConnectionsLimit},
+		{"query_timeout_seconds", queryTimeout, 0, MaxQueryTimeoutSeconds},
+		{"max_idle_connections", maxIdle, 0, MaxConnectionsLimit},
+		{"conn_max_idle_time_seconds", maxIdleTime, 0, MaxConnDurationSeconds},
+		{"conn_max_lifetime_seconds", maxLifetime, 0, MaxConnDurationSeconds},
+	}
+
+	for _, check := range checks {
+		if check.value != nil && (*check.value < check.min || *check.value > check.max) {
+			return fmt.Errorf("%s must be between %d and %d", check.name, check.min, check.max)
+		}
+	}
+
+	return nil
 }
 
 func (ConnectionData) TableName() string {
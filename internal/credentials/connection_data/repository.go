@@ -13,6 +13,9 @@ import (
 type Repository struct {
 	db *sqlx.DB
 	cm *crypto.CryptoManager
+	// onChange is called with the ID of every connection whose DSN, dialect,
+	// read-only flag or activity changes. Listeners are registered at startup.
+	onChange []func(connectionID string)
 }
 
 func NewConnectionDataRepository(db *sqlx.DB, cm *crypto.CryptoManager) *Repository {
@@ -22,35 +25,59 @@ func NewConnectionDataRepository(db *sqlx.DB, cm *crypto.CryptoManager) *Reposit
 	}
 }
 
+// OnChange registers fn to be called after a connection is reconfigured or
+// deleted, so that whoever caches adapters for it can drop them. It must not
+// be called concurrently with repository use.
+func (r *Repository) OnChange(fn func(connectionID string)) {
+	r.onChange = append(r.onChange, fn)
+}
+
+func (r *Repository) notifyChange(connectionID string) {
+	for _, fn := range r.onChange {
+		fn(connectionID)
+	}
+}
+
 func (r *Repository) InsertConnection(data NewConnectionData) (*ConnectionDataQuery, error) {
-	envelope, err := r.cm.Encrypt([]byte(data.DSN))
+	id := generateConnectionID()
+
+	if data.MaxConnections < 1 {
+		data.MaxConnections = DefaultMaxConnections
+	}
+
+	envelope, err := r.cm.Encrypt([]byte(data.DSN), AssociatedData(data.TenantID, id))
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt DSN: %w", err)
 	}
 
-	id := generateConnectionID()
-
 	params := map[string]interface{}{
-		"id":              id,
-		"tenant_id":       data.TenantID,
-		"name":            data.Name,
-		"description":     data.Description,
-		"dsn":             envelope.CiphertextHex,
-		"dialect":         data.Dialect,
-		"dek":             envelope.WrappedDEKHex,
-		"readonly":        data.ReadOnly,
-		"max_connections": data.MaxConnections,
-		"is_active":       true,
+		"id":                         id,
+		"tenant_id":                  data.TenantID,
+		"name":                       data.Name,
+		"description":                data.Description,
+		"dsn":                        envelope.CiphertextHex,
+		"dialect":                    data.Dialect,
+		"dek":                        envelope.WrappedDEKHex,
+		"dek_key_id":                 envelope.KeyID,
+		"readonly":                   data.ReadOnly,
+		"max_connections":            data.MaxConnections,
+		"query_timeout_seconds":      data.QueryTimeout,
+		"max_idle_connections":       intOrDefault(data.MaxIdleConnections, DefaultMaxIdleConnections),
+		"conn_max_idle_time_seconds": intOrDefault(data.ConnMaxIdleTime, DefaultConnMaxIdleTimeSeconds),
+		"conn_max_lifetime_seconds":  intOrDefault(data.ConnMaxLifetime, DefaultConnMaxLifetimeSeconds),
+		"is_active":                  true,
 	}
 
 	query := `
 		INSERT INTO connection_data (
-			id, tenant_id, name, description, dsn, dialect, dek, 
-			readonly, max_connections, is_active
+			id, tenant_id, name, description, dsn, dialect, dek, dek_key_id,
+			readonly, max_connections, query_timeout_seconds, max_idle_connections,
+			conn_max_idle_time_seconds, conn_max_lifetime_seconds, is_active
 		)
 		VALUES (
-			:id, :tenant_id, :name, :description, :dsn, :dialect, :dek,
-			:readonly, :max_connections, :is_active
+			:id, :tenant_id, :name, :description, :dsn, :dialect, :dek, :dek_key_id,
+			:readonly, :max_connections, :query_timeout_seconds, :max_idle_connections,
+			:conn_max_idle_time_seconds, :conn_max_lifetime_seconds, :is_active
 		)`
 
 	_, err = r.db.NamedExec(query, params)
@@ -67,7 +94,8 @@ func (r *Repository) GetConnectionByID(tenantID, connectionID string) (*Connecti
 	query := `
 		SELECT
 			id, tenant_id, name, description, dialect, 
-			readonly, max_connections, is_active, created_at, updated_at
+			readonly, max_connections, query_timeout_seconds, max_idle_connections,
+			conn_max_idle_time_seconds, conn_max_lifetime_seconds, is_active, created_at, updated_at
 		FROM connection_data
 		WHERE id = ? AND tenant_id = ? AND is_active = 1
 	`
@@ -81,16 +109,20 @@ func (r *Repository) GetConnectionByID(tenantID, connectionID string) (*Connecti
 	}
 
 	return &ConnectionDataQuery{
-		ID:             cred.ID,
-		TenantID:       cred.TenantID,
-		Name:           cred.Name,
-		Description:    cred.Description,
-		Dialect:        cred.Dialect,
-		ReadOnly:       cred.ReadOnly,
-		MaxConnections: cred.MaxConnections,
-		IsActive:       cred.IsActive,
-		CreatedAt:      cred.CreatedAt,
-		UpdatedAt:      cred.UpdatedAt,
+		ID:                 cred.ID,
+		TenantID:           cred.TenantID,
+		Name:               cred.Name,
+		Description:        cred.Description,
+		Dialect:            cred.Dialect,
+		ReadOnly:           cred.ReadOnly,
+		MaxConnections:     cred.MaxConnections,
+		QueryTimeout:       cred.QueryTimeout,
+		MaxIdleConnections: cred.MaxIdleConnections,
+		ConnMaxIdleTime:    cred.ConnMaxIdleTime,
+		ConnMaxLifetime:    cred.ConnMaxLifetime,
+		IsActive:           cred.IsActive,
+		CreatedAt:          cred.CreatedAt,
+		UpdatedAt:          cred.UpdatedAt,
 	}, nil
 }
 
@@ -99,8 +131,9 @@ func (r *Repository) GetConnectionWithDSN(tenantID, connectionID string) (*Conne
 
 	query := `
 		SELECT
-			id, tenant_id, name, description, dsn, dialect, dek,
-			readonly, max_connections, is_active, created_at, updated_at
+			id, tenant_id, name, description, dsn, dialect, dek, dek_key_id,
+			readonly, max_connections, query_timeout_seconds, max_idle_connections,
+			conn_max_idle_time_seconds, conn_max_lifetime_seconds, is_active, created_at, updated_at
 		FROM connection_data
 		WHERE id = ? AND tenant_id = ? AND is_active = 1
 	`
@@ -116,9 +149,12 @@ func (r *Repository) GetConnectionWithDSN(tenantID, connectionID string) (*Conne
 	envelope := &crypto.Envelope{
 		CiphertextHex: cred.DSN,
 		WrappedDEKHex: cred.DEK,
+		KeyID:         cred.DEKKeyID,
 	}
 
-	plainDSN, err := r.cm.Decrypt(envelope)
+	// Decryption fails if the dsn or dek columns were copied from another
+	// row, since the ciphertext is bound to its tenant and connection IDs.
+	plainDSN, err := r.cm.Decrypt(envelope, AssociatedData(cred.TenantID, cred.ID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt DSN: %w", err)
 	}
@@ -127,13 +163,56 @@ func (r *Repository) GetConnectionWithDSN(tenantID, connectionID string) (*Conne
 	return &cred, nil
 }
 
+// ListActiveConnectionsWithDSN returns every active connection of every
+// tenant with its DSN decrypted. Connections whose DSN cannot be decrypted
+// are reported in the error but do not prevent the others from being listed.
+func (r *Repository) ListActiveConnectionsWithDSN() ([]*ConnectionData, error) {
+	var rows []*ConnectionData
+
+	query := `
+		SELECT
+			id, tenant_id, name, description, dsn, dialect, dek, dek_key_id,
+			readonly, max_connections, query_timeout_seconds, max_idle_connections,
+			conn_max_idle_time_seconds, conn_max_lifetime_seconds, is_active, created_at, updated_at
+		FROM connection_data
+		WHERE is_active = 1
+	`
+
+	err := r.db.Select(&rows, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active connections: %w", err)
+	}
+
+	connections := make([]*ConnectionData, 0, len(rows))
+	var decryptErrs []error
+	for _, cred := range rows {
+		envelope := &crypto.Envelope{
+			CiphertextHex: cred.DSN,
+			WrappedDEKHex: cred.DEK,
+			KeyID:         cred.DEKKeyID,
+		}
+
+		plainDSN, err := r.cm.Decrypt(envelope, AssociatedData(cred.TenantID, cred.ID))
+		if err != nil {
+			decryptErrs = append(decryptErrs, fmt.Errorf("failed to decrypt DSN of connection %s: %w", cred.ID, err))
+			continue
+		}
+
+		cred.DSN = string(plainDSN)
+		connections = append(connections, cred)
+	}
+
+	return connections, errors.Join(decryptErrs...)
+}
+
 func (r *Repository) ListConnections(tenantID string) ([]*ConnectionDataQuery, error) {
 	var connections []*ConnectionDataQuery
 
 	query := `
 		SELECT
 			id, tenant_id, name, description, dialect, 
-			readonly, max_connections, is_active, created_at, updated_at
+			readonly, max_connections, query_timeout_seconds, max_idle_connections,
+			conn_max_idle_time_seconds, conn_max_lifetime_seconds, is_active, created_at, updated_at
 		FROM connection_data
 		WHERE tenant_id = ? AND is_active = 1
 		ORDER BY created_at DESC
@@ -148,82 +227,76 @@ func (r *Repository) ListConnections(tenantID string) ([]*ConnectionDataQuery, e
 }
 
 func (r *Repository) UpdateConnection(tenantID, connectionID string, update UpdateConnectionData) error {
+	query := `
+		UPDATE connection_data SET
+			name = COALESCE(:name, name),
+			description = COALESCE(:description, description),
+			dialect = COALESCE(:dialect, dialect),
+			readonly = COALESCE(:readonly, readonly),
+			max_connections = COALESCE(:max_connections, max_connections),
+			query_timeout_seconds = COALESCE(:query_timeout_seconds, query_timeout_seconds),
+			max_idle_connections = COALESCE(:max_idle_connections, max_idle_connections),
+			conn_max_idle_time_seconds = COALESCE(:conn_max_idle_time_seconds, conn_max_idle_time_seconds),
+			conn_max_lifetime_seconds = COALESCE(:conn_max_lifetime_seconds, conn_max_lifetime_seconds),
+			is_active = COALESCE(:is_active, is_active),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = :id AND tenant_id = :tenant_id
+	`
+
+	params := map[string]interface{}{
+		"id":                         connectionID,
+		"tenant_id":                  tenantID,
+		"name":                       update.Name,
+		"description":                update.Description,
+		"dialect":                    update.Dialect,
+		"readonly":                   update.ReadOnly,
+		"max_connections":            update.MaxConnections,
+		"query_timeout_seconds":      update.QueryTimeout,
+		"max_idle_connections":       update.MaxIdleConnections,
+		"conn_max_idle_time_seconds": update.ConnMaxIdleTime,
+		"conn_max_lifetime_seconds":  update.ConnMaxLifetime,
+		"is_active":                  update.IsActive,
+	}
+
 	if update.DSN != nil {
-		envelope, err := r.cm.Encrypt([]byte(*update.DSN))
+		envelope, err := r.cm.Encrypt([]byte(*update.DSN), AssociatedData(tenantID, connectionID))
 		if err != nil {
 			return fmt.Errorf("failed to encrypt DSN: %w", err)
 		}
-		encryptedDSN := envelope.CiphertextHex
-		encryptedDEK := envelope.WrappedDEKHex
-		update.DSN = &encryptedDSN
 
-		query := `
+		query = `
 			UPDATE connection_data SET
 				name = COALESCE(:name, name),
 				description = COALESCE(:description, description),
-				dsn = COALESCE(:dsn, dsn),
+				dsn = :dsn,
 				dek = :dek,
+				dek_key_id = :dek_key_id,
 				dialect = COALESCE(:dialect, dialect),
 				readonly = COALESCE(:readonly, readonly),
 				max_connections = COALESCE(:max_connections, max_connections),
+				query_timeout_seconds = COALESCE(:query_timeout_seconds, query_timeout_seconds),
+				max_idle_connections = COALESCE(:max_idle_connections, max_idle_connections),
+				conn_max_idle_time_seconds = COALESCE(:conn_max_idle_time_seconds, conn_max_idle_time_seconds),
+				conn_max_lifetime_seconds = COALESCE(:conn_max_lifetime_seconds, conn_max_lifetime_seconds),
 				is_active = COALESCE(:is_active, is_active),
 				updated_at = CURRENT_TIMESTAMP
 			WHERE id = :id AND tenant_id = :tenant_id
 		`
 
-		params := map[string]interface{}{
-			"id":              connectionID,
-			"tenant_id":       tenantID,
-			"name":            update.Name,
-			"description":     update.Description,
-			"dsn":             update.DSN,
-			"dek":             encryptedDEK,
-			"dialect":         update.Dialect,
-			"readonly":        update.ReadOnly,
-			"max_connections": update.MaxConnections,
-			"is_active":       update.IsActive,
-		}
-
-		result, err := r.db.NamedExec(query, params)
-		if err != nil {
-			return fmt.Errorf("failed to update connection: %w", err)
-		}
-
-		rowsAffected, err := result.RowsAffected()
-		if err != nil {
-			return fmt.Errorf("failed to get rows affected: %w", err)
-		}
-		if rowsAffected == 0 {
-			return sql.ErrNoRows
-		}
-
-		return nil
+		params["dsn"] = envelope.CiphertextHex
+		params["dek"] = envelope.WrappedDEKHex
+		params["dek_key_id"] = envelope.KeyID
 	}
 
-	query := `
-		UPDATE connection_data SET
-			name = COALESCE(:name, name),
-			description = COALESCE(:description, description),
-			dialect = COALESCE(:dialect, dialect),
-			readonly = COALESCE(:readonly, readonly),
-			max_connections = COALESCE(:max_connections, max_connections),
-			is_active = COALESCE(:is_active, is_active),
-			updated_at = CURRENT_TIMESTAMP
-		WHERE id = :id AND tenant_id = :tenant_id
-	`
-
-	params := map[string]interface{}{
-		"id":              connectionID,
-		"tenant_id":       tenantID,
-		"name":            update.Name,
-		"description":     update.Description,
-		"dialect":         update.Dialect,
-		"readonly":        update.ReadOnly,
-		"max_connections": update.MaxConnections,
-		"is_active":       update.IsActive,
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer func(tx *sqlx.Tx) {
+		_ = tx.Rollback()
+	}(tx)
 
-	result, err := r.db.NamedExec(query, params)
+	result, err := tx.NamedExec(query, params)
 	if err != nil {
 		return fmt.Errorf("failed to update connection: %w", err)
 	}
@@ -236,9 +309,27 @@ func (r *Repository) UpdateConnection(tenantID, connectionID string, update Upda
 		return sql.ErrNoRows
 	}
 
+	if update.IsActive != nil && !*update.IsActive {
+		if err := revokeConnectionTokens(tx, tenantID, connectionID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit connection update: %w", err)
+	}
+
+	// Pool settings are picked up on next use; anything else needs a new
+	// adapter.
+	if update.DSN != nil || update.Dialect != nil || update.ReadOnly != nil || update.IsActive != nil {
+		r.notifyChange(connectionID)
+	}
+
 	return nil
 }
 
+// DeleteConnection deactivates the connection and revokes every token that
+// grants access to it.
 func (r *Repository) DeleteConnection(tenantID, connectionID string) error {
 	query := `
 		UPDATE connection_data 
@@ -246,7 +337,15 @@ func (r *Repository) DeleteConnection(tenantID, connectionID string) error {
 		WHERE id = ? AND tenant_id = ?
 	`
 
-	result, err := r.db.Exec(query, connectionID, tenantID)
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sqlx.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	result, err := tx.Exec(query, connectionID, tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to delete connection: %w", err)
 	}
@@ -259,9 +358,50 @@ func (r *Repository) DeleteConnection(tenantID, connectionID string) error {
 		return sql.ErrNoRows
 	}
 
+	if err := revokeConnectionTokens(tx, tenantID, connectionID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit connection deletion: %w", err)
+	}
+
+	r.notifyChange(connectionID)
+	return nil
+}
+
+// revokeConnectionTokens revokes the outstanding tokens whose connection
+// list includes connectionID. Tokens are revoked as a whole, even if they
+// also grant other connections.
+func revokeConnectionTokens(tx *sqlx.Tx, tenantID, connectionID string) error {
+	query := `
+		UPDATE jwt_tokens
+		SET revoked = 1, revoked_at = CURRENT_TIMESTAMP
+		WHERE tenant_id = ? AND revoked = 0
+			AND EXISTS (SELECT 1 FROM json_each(jwt_tokens.connection_ids) WHERE value = ?)
+	`
+
+	if _, err := tx.Exec(query, tenantID, connectionID); err != nil {
+		return fmt.Errorf("failed to revoke connection tokens: %w", err)
+	}
+
 	return nil
 }
 
+// ListActiveConnectionIDs returns the IDs of the tenant's active connections.
+func (r *Repository) ListActiveConnectionIDs(tenantID string) ([]string, error) {
+	var ids []string
+
+	query := `SELECT id FROM connection_data WHERE tenant_id = ? AND is_active = 1`
+
+	err := r.db.Select(&ids, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active connections: %w", err)
+	}
+
+	return ids, nil
+}
+
 func (r *Repository) HardDeleteConnection(tenantID, connectionID string) error {
 	query := `
 		DELETE FROM connection_data
@@ -281,9 +421,80 @@ func (r *Repository) HardDeleteConnection(tenantID, connectionID string) error {
 		return sql.ErrNoRows
 	}
 
+	r.notifyChange(connectionID)
 	return nil
 }
 
+// RewrapDEKs re-wraps every DEK that is not yet wrapped with the active master
+// key, including those of deactivated connections. DSN ciphertexts are not
+// touched. It returns how many rows were updated.
+func (r *Repository) RewrapDEKs() (int, error) {
+	var rows []ConnectionData
+
+	query := `
+		SELECT id, tenant_id, dsn, dek, dek_key_id
+		FROM connection_data
+		WHERE dek_key_id != ?
+	`
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sqlx.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	err = tx.Select(&rows, query, r.cm.ActiveKeyID())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list connections to rewrap: %w", err)
+	}
+
+	rewrapped := 0
+	for _, row := range rows {
+		envelope, err := r.cm.RewrapDEK(&crypto.Envelope{
+			CiphertextHex: row.DSN,
+			WrappedDEKHex: row.DEK,
+			KeyID:         row.DEKKeyID,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to rewrap DEK of connection %s: %w", row.ID, err)
+		}
+
+		result, err := tx.Exec(
+			`UPDATE connection_data SET dek = ?, dek_key_id = ? WHERE id = ? AND dek = ?`,
+			envelope.WrappedDEKHex, envelope.KeyID, row.ID, row.DEK,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update DEK of connection %s: %w", row.ID, err)
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		rewrapped += int(updated)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rewrapped DEKs: %w", err)
+	}
+
+	return rewrapped, nil
+}
+
+// AssociatedData is the AEAD associated data a connection's DSN is encrypted
+// with. The IDs are NUL-separated so that no two pairs share an encoding.
+func AssociatedData(tenantID, connectionID string) []byte {
+	return []byte("pinoql:connection_data:" + tenantID + "\x00" + connectionID)
+}
+
+func intOrDefault(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
 func generateConnectionID() string {
 	return fmt.Sprintf("conn_%s", uuid.New().String()[:8])
 }
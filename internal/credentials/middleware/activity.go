@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/tenant"
+)
+
+const DefaultActivityCacheTTL = 30 * time.Second
+
+// activityCacheSweepSize is the cache size at which expired entries are first
+// pruned; later sweeps wait for the cache to double past what survived.
+const activityCacheSweepSize = 1024
+
+// ActivityChecker rejects tokens whose tenant or connections have been
+// deactivated. Deactivation also revokes stored tokens, but tokens from an
+// external identity provider are never stored, so they rely on this check.
+// Results are cached per tenant for a short while to keep it off the hot
+// path.
+type ActivityChecker struct {
+	tenantRepo *tenant.Repository
+	connRepo   *connection_data.Repository
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	tenants map[string]*tenantActivity
+	sweepAt int
+}
+
+type tenantActivity struct {
+	active      bool
+	connections map[string]bool
+	fetchedAt   time.Time
+}
+
+func NewActivityChecker(tenantRepo *tenant.Repository, connRepo *connection_data.Repository, ttl time.Duration) *ActivityChecker {
+	if ttl <= 0 {
+		ttl = DefaultActivityCacheTTL
+	}
+
+	return &ActivityChecker{
+		tenantRepo: tenantRepo,
+		connRepo:   connRepo,
+		ttl:        ttl,
+		tenants:    map[string]*tenantActivity{},
+		sweepAt:    activityCacheSweepSize,
+	}
+}
+
+func (a *ActivityChecker) Check(pinoqlClaims *claims.PinoQLClaims) error {
+	activity, err := a.activity(pinoqlClaims.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if !activity.active {
+		return fmt.Errorf("tenant is not active")
+	}
+
+	for _, connID := range pinoqlClaims.ConnectionIDs {
+		if connID != "*" && !activity.connections[connID] {
+			return fmt.Errorf("connection %s is not active", connID)
+		}
+	}
+
+	return nil
+}
+
+func (a *ActivityChecker) activity(tenantID string) (*tenantActivity, error) {
+	a.mu.Lock()
+	cached, ok := a.tenants[tenantID]
+	a.mu.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < a.ttl {
+		return cached, nil
+	}
+
+	activity, err := a.fetch(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.tenants[tenantID] = activity
+	if len(a.tenants) >= a.sweepAt {
+		a.sweep()
+	}
+	a.mu.Unlock()
+
+	return activity, nil
+}
+
+// sweep drops expired entries so that tokens naming many tenants cannot grow
+// the cache without bound. The caller must hold a.mu.
+func (a *ActivityChecker) sweep() {
+	for tenantID, activity := range a.tenants {
+		if time.Since(activity.fetchedAt) >= a.ttl {
+			delete(a.tenants, tenantID)
+		}
+	}
+	a.sweepAt = max(activityCacheSweepSize, 2*len(a.tenants))
+}
+
+// fetch loads the activity of a tenant from the database. Failed lookups are
+// returned as errors so that they are neither cached nor mistaken for an
+// inactive tenant.
+func (a *ActivityChecker) fetch(tenantID string) (*tenantActivity, error) {
+	activity := &tenantActivity{
+		connections: map[string]bool{},
+		fetchedAt:   time.Now(),
+	}
+
+	// GetTenantByID only finds active tenants.
+	_, err := a.tenantRepo.GetTenantByID(tenantID)
+	if errors.Is(err, tenant.ErrTenantNotFound) {
+		return activity, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check tenant activity: %w", err)
+	}
+
+	activity.active = true
+
+	ids, err := a.connRepo.ListActiveConnectionIDs(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check connection activity: %w", err)
+	}
+	for _, id := range ids {
+		activity.connections[id] = true
+	}
+
+	return activity, nil
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/tenant"
+	"github.com/CaioMtho/pinoql-mcp/internal/crypto"
+	"github.com/CaioMtho/pinoql-mcp/internal/migrate"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestActivityChecker(t *testing.T) *ActivityChecker {
+	t.Helper()
+
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	masterKey := make([]byte, 32)
+	_, _ = rand.Read(masterKey)
+	cm, err := crypto.NewCryptoManager(masterKey)
+	if err != nil {
+		t.Fatalf("NewCryptoManager: %v", err)
+	}
+
+	migrator, err := migrate.NewMigrator(db, cm)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO tenants (id, name, is_active) VALUES ('t1', 't1', 1)`); err != nil {
+		t.Fatalf("insert tenant: %v", err)
+	}
+
+	return NewActivityChecker(tenant.NewTenantRepository(db), connection_data.NewConnectionDataRepository(db, cm), time.Minute)
+}
+
+func TestActivityCacheSweepsExpiredTenants(t *testing.T) {
+	checker := newTestActivityChecker(t)
+
+	// Unknown tenants are cached as inactive, like those named by forged or
+	// stale tokens.
+	for i := range activityCacheSweepSize - 1 {
+		err := checker.Check(&claims.PinoQLClaims{TenantID: fmt.Sprintf("gone-%d", i)})
+		if err == nil {
+			t.Fatalf("Check accepted an unknown tenant")
+		}
+	}
+
+	checker.mu.Lock()
+	for _, activity := range checker.tenants {
+		activity.fetchedAt = time.Now().Add(-time.Hour)
+	}
+	checker.mu.Unlock()
+
+	if err := checker.Check(&claims.PinoQLClaims{TenantID: "t1"}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	checker.mu.Lock()
+	size, sweepAt := len(checker.tenants), checker.sweepAt
+	_, cached := checker.tenants["t1"]
+	checker.mu.Unlock()
+
+	if size != 1 || !cached {
+		t.Fatalf("cache holds %d tenants after the sweep, want only t1", size)
+	}
+	if sweepAt != activityCacheSweepSize {
+		t.Fatalf("sweepAt = %d, want %d", sweepAt, activityCacheSweepSize)
+	}
+}
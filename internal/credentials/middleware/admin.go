@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AdminClaimsKey is the key under which verified tenant-admin claims are
+// stored in the gin context.
+const AdminClaimsKey = "admin_claims"
+
+// RequireSuperAdmin guards tenant management with the bootstrap credential
+// configured at startup. When none is configured those routes stay closed.
+func (m *AuthMiddleware) RequireSuperAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.superAdminToken == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "super-admin access is not configured"})
+			c.Abort()
+			return
+		}
+
+		tokenString, ok := bearerToken(c, "")
+		if !ok {
+			return
+		}
+
+		// Hashing first keeps the comparison constant-time regardless of the
+		// length of the presented token.
+		presented := sha256.Sum256([]byte(tokenString))
+		expected := sha256.Sum256([]byte(m.superAdminToken))
+		if subtle.ConstantTimeCompare(presented[:], expected[:]) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid admin credentials"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireTenantAdmin accepts tenant-admin tokens and scopes the request to
+// their tenant by setting tenant_id. Agent tokens are rejected.
+func (m *AuthMiddleware) RequireTenantAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c, "")
+		if !ok {
+			return
+		}
+
+		adminClaims, err := m.authenticateAdmin(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("tenant_id", adminClaims.TenantID)
+		c.Set(AdminClaimsKey, adminClaims)
+
+		c.Next()
+	}
+}
+
+// RequireTenantAccess accepts either a tenant-admin token or an API key
+// carrying scope, so automation can manage a tenant without holding a full
+// admin credential.
+func (m *AuthMiddleware) RequireTenantAccess(scope string) gin.HandlerFunc {
+	requireAdmin := m.RequireTenantAdmin()
+	requireAPIKey := m.RequireAPIKey(scope)
+
+	return func(c *gin.Context) {
+		if c.GetHeader("X-API-Key") != "" {
+			requireAPIKey(c)
+			return
+		}
+		requireAdmin(c)
+	}
+}
+
+func (m *AuthMiddleware) authenticateAdmin(tokenString string) (*claims.AdminClaims, error) {
+	options := append(m.signer.ParserOptions(), jwt.WithAudience(claims.AdminAudience), jwt.WithExpirationRequired())
+	authToken, err := jwt.ParseWithClaims(tokenString, &claims.AdminClaims{}, m.signer.Keyfunc, options...)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	adminClaims, ok := authToken.Claims.(*claims.AdminClaims)
+	if !ok || !authToken.Valid || adminClaims.TenantID == "" {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+
+	revoked, err := m.adminRepo.IsTokenRevoked(adminClaims.ID)
+	if err != nil || revoked {
+		return nil, fmt.Errorf("token has been revoked")
+	}
+
+	return adminClaims, nil
+}
@@ -1,80 +1,225 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/admin"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/apikey"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/oauth"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/oidc"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/signing"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/token"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/modelcontextprotocol/go-sdk/auth"
 )
 
+// ClaimsKey is the key under which verified claims are stored, both in the gin
+// context and in the TokenInfo extras handed to MCP tool handlers.
+const ClaimsKey = "claims"
+
+// APIKeyKey is the key under which the verified API key is stored in the gin
+// context.
+const APIKeyKey = "api_key"
+
 type AuthMiddleware struct {
-	jwtSecret string
-	tokenRepo *token.Repository
+	signer          *signing.KeyManager
+	oidcVerifier    *oidc.Verifier
+	activity        *ActivityChecker
+	tokenRepo       *token.Repository
+	adminRepo       *admin.Repository
+	apiKeyRepo      *apikey.Repository
+	superAdminToken string
+	// resource and resourceMetadataURL are set when the server advertises
+	// OAuth protected-resource metadata for the MCP endpoint.
+	resource            string
+	resourceMetadataURL string
 }
 
-func NewAuthMiddleware(jwtSecret string, tokenRepo *token.Repository) *AuthMiddleware {
-	return &AuthMiddleware{
-		jwtSecret: jwtSecret,
-		tokenRepo: tokenRepo,
+// NewAuthMiddleware creates the middleware. oidcVerifier is optional; when
+// set, bearer tokens issued by that provider are accepted alongside the ones
+// PinoQL signs itself. publicURL is optional; when set, failed requests carry
+// a WWW-Authenticate challenge pointing at the protected-resource metadata,
+// and tokens issued for a specific resource must be issued for ours.
+func NewAuthMiddleware(
+	signer *signing.KeyManager,
+	oidcVerifier *oidc.Verifier,
+	activity *ActivityChecker,
+	tokenRepo *token.Repository,
+	adminRepo *admin.Repository,
+	apiKeyRepo *apikey.Repository,
+	superAdminToken string,
+	publicURL string,
+) *AuthMiddleware {
+	m := &AuthMiddleware{
+		signer:          signer,
+		oidcVerifier:    oidcVerifier,
+		activity:        activity,
+		tokenRepo:       tokenRepo,
+		adminRepo:       adminRepo,
+		apiKeyRepo:      apiKeyRepo,
+		superAdminToken: superAdminToken,
+	}
+
+	if publicURL != "" {
+		m.resource = oauth.ResourceURL(publicURL)
+		m.resourceMetadataURL = oauth.ResourceMetadataURL(publicURL)
 	}
+
+	return m
 }
 
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
-			c.Abort()
+		tokenString, ok := bearerToken(c, m.challenge(""))
+		if !ok {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+		pinoqlClaims, err := m.authenticate(c.Request.Context(), tokenString)
+		if err != nil {
+			if challenge := m.challenge("invalid_token"); challenge != "" {
+				c.Header("WWW-Authenticate", challenge)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
+		c.Set("tenant_id", pinoqlClaims.TenantID)
+		c.Set(ClaimsKey, pinoqlClaims)
 
-		authToken, err := jwt.ParseWithClaims(tokenString, &claims.PinoQLClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(m.jwtSecret), nil
-		})
+		c.Next()
+	}
+}
+
+// RequireMCPAuth wraps the MCP transport handler so that every request carries
+// a verified bearer token. The parsed claims reach tool handlers through
+// CallToolRequest.Extra.TokenInfo.
+func (m *AuthMiddleware) RequireMCPAuth(next http.Handler) http.Handler {
+	return auth.RequireBearerToken(m.VerifyToken, &auth.RequireBearerTokenOptions{
+		ResourceMetadataURL: m.resourceMetadataURL,
+	})(next)
+}
+
+// VerifyToken implements auth.TokenVerifier on top of the PinoQL JWTs.
+func (m *AuthMiddleware) VerifyToken(ctx context.Context, tokenString string, _ *http.Request) (*auth.TokenInfo, error) {
+	pinoqlClaims, err := m.authenticate(ctx, tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidToken, err)
+	}
+
+	expiration := pinoqlClaims.ExpiresAt
+	if expiration == nil {
+		return nil, fmt.Errorf("%w: token missing expiration", auth.ErrInvalidToken)
+	}
+
+	return &auth.TokenInfo{
+		UserID:     pinoqlClaims.TenantID,
+		Expiration: expiration.Time,
+		Extra: map[string]any{
+			ClaimsKey: pinoqlClaims,
+		},
+	}, nil
+}
 
+// ClaimsFromTokenInfo returns the claims stored by VerifyToken, if any.
+func ClaimsFromTokenInfo(info *auth.TokenInfo) (*claims.PinoQLClaims, bool) {
+	if info == nil || info.Extra == nil {
+		return nil, false
+	}
+	pinoqlClaims, ok := info.Extra[ClaimsKey].(*claims.PinoQLClaims)
+	return pinoqlClaims, ok
+}
+
+func (m *AuthMiddleware) authenticate(ctx context.Context, tokenString string) (*claims.PinoQLClaims, error) {
+	var pinoqlClaims *claims.PinoQLClaims
+
+	if m.oidcVerifier != nil && m.oidcVerifier.Handles(tokenString) {
+		var err error
+		pinoqlClaims, err = m.oidcVerifier.Verify(ctx, tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
+			return nil, err
 		}
+	} else {
+		authToken, err := jwt.ParseWithClaims(tokenString, &claims.PinoQLClaims{}, m.signer.Keyfunc, m.signer.ParserOptions()...)
 
-		pinoqlClaims, ok := authToken.Claims.(*claims.PinoQLClaims)
-		if !ok || !authToken.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
-			return
+		if err != nil {
+			return nil, fmt.Errorf("invalid token")
 		}
 
-		revoked, err := m.tokenRepo.IsTokenRevoked(pinoqlClaims.ID)
-		if err != nil || revoked {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
-			c.Abort()
-			return
+		var ok bool
+		pinoqlClaims, ok = authToken.Claims.(*claims.PinoQLClaims)
+		if !ok || !authToken.Valid || pinoqlClaims.IsAdminToken() {
+			return nil, fmt.Errorf("invalid token claims")
 		}
 
-		c.Set("tenant_id", pinoqlClaims.TenantID)
-		c.Set("claims", pinoqlClaims)
+		// Tokens without an audience predate OAuth and are accepted anywhere;
+		// tokens with one must have been issued for this server (RFC 8707).
+		if m.resource != "" && len(pinoqlClaims.Audience) > 0 && !slices.Contains(pinoqlClaims.Audience, m.resource) {
+			return nil, fmt.Errorf("token was issued for another resource")
+		}
+	}
 
-		c.Next()
+	revoked, err := m.tokenRepo.IsTokenRevoked(pinoqlClaims.ID)
+	if err != nil || revoked {
+		return nil, fmt.Errorf("token has been revoked")
+	}
+
+	if err := m.activity.Check(pinoqlClaims); err != nil {
+		return nil, err
 	}
+
+	return pinoqlClaims, nil
 }
 
-func (m *AuthMiddleware) RequireAPIKey() gin.HandlerFunc {
+// challenge builds the WWW-Authenticate value for a failed request, or ""
+// when no protected-resource metadata is advertised.
+func (m *AuthMiddleware) challenge(errorCode string) string {
+	if m.resourceMetadataURL == "" {
+		return ""
+	}
+	value := fmt.Sprintf(`Bearer resource_metadata="%s"`, m.resourceMetadataURL)
+	if errorCode != "" {
+		value += fmt.Sprintf(`, error="%s"`, errorCode)
+	}
+	return value
+}
+
+// bearerToken extracts the token from the Authorization header, aborting the
+// request when it is missing or malformed. A non-empty challenge is sent as
+// the WWW-Authenticate header of that response.
+func bearerToken(c *gin.Context, challenge string) (string, bool) {
+	fail := func(message string) (string, bool) {
+		if challenge != "" {
+			c.Header("WWW-Authenticate", challenge)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+		c.Abort()
+		return "", false
+	}
+
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return fail("missing authorization header")
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return fail("invalid authorization header format")
+	}
+
+	return parts[1], true
+}
+
+// RequireAPIKey accepts an X-API-Key header whose key grants every scope in
+// requiredScopes, and scopes the request to the key's tenant.
+func (m *AuthMiddleware) RequireAPIKey(requiredScopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		apiKey := c.GetHeader("X-API-Key")
 		if apiKey == "" {
@@ -83,18 +228,27 @@ func (m *AuthMiddleware) RequireAPIKey() gin.HandlerFunc {
 			return
 		}
 
-		tenantID, err := m.validateAPIKey(apiKey)
+		key, err := m.validateAPIKey(apiKey)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
 			c.Abort()
 			return
 		}
 
-		c.Set("tenant_id", tenantID)
+		for _, scope := range requiredScopes {
+			if !key.HasScope(scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "API key lacks scope: " + scope})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("tenant_id", key.TenantID)
+		c.Set(APIKeyKey, key)
 		c.Next()
 	}
 }
 
-func (m *AuthMiddleware) validateAPIKey(apiKey string) (string, error) {
-	return "", nil
+func (m *AuthMiddleware) validateAPIKey(apiKey string) (*apikey.VerifiedAPIKey, error) {
+	return m.apiKeyRepo.VerifyKey(apiKey)
 }
@@ -0,0 +1,40 @@
+package oauth
+
+import "html/template"
+
+type consentPage struct {
+	ClientName string
+	Scopes     []string
+	Params     map[string]string
+	Error      string
+}
+
+var consentTemplate = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Authorize {{.ClientName}}</title>
+</head>
+<body>
+<h1>Authorize {{.ClientName}}</h1>
+<p>This application is requesting the following access to your PinoQL connections:</p>
+<ul>
+{{range .Scopes}}<li>{{.}}</li>
+{{end}}</ul>
+{{if .Error}}<p><strong>{{.Error}}</strong></p>{{end}}
+<form method="post" action="authorize">
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}<p>
+<label>API key with the "tokens" scope<br>
+<input type="password" name="api_key" autocomplete="off" required></label>
+</p>
+<p>
+<label>Connection IDs, comma-separated<br>
+<input type="text" name="connection_ids" required></label>
+</p>
+<button type="submit" name="action" value="approve">Approve</button>
+<button type="submit" name="action" value="deny" formnovalidate>Deny</button>
+</form>
+</body>
+</html>
+`))
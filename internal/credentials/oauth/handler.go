@@ -0,0 +1,449 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/apikey"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/signing"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/token"
+	"github.com/gin-gonic/gin"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
+)
+
+const (
+	DefaultTokenTTL = time.Hour
+	codeTTL         = time.Minute
+)
+
+// ResourceURL is the resource identifier of the MCP endpoint, which OAuth
+// tokens are issued for.
+func ResourceURL(publicURL string) string {
+	return strings.TrimRight(publicURL, "/") + "/mcp"
+}
+
+// ResourceMetadataURL is where the protected-resource metadata of the MCP
+// endpoint is served, following the path insertion rule of RFC 9728.
+func ResourceMetadataURL(publicURL string) string {
+	return strings.TrimRight(publicURL, "/") + "/.well-known/oauth-protected-resource/mcp"
+}
+
+type Config struct {
+	// PublicURL is the externally visible base URL of the server. It is the
+	// issuer of the built-in authorization server.
+	PublicURL string
+	// AuthorizationServers are advertised alongside the built-in one, e.g.
+	// a trusted OIDC provider.
+	AuthorizationServers []string
+	TokenTTL             time.Duration
+}
+
+// Handler implements the MCP authorization flow: protected-resource and
+// authorization-server metadata, dynamic client registration, and an
+// authorization code grant with mandatory PKCE. Users approve a request by
+// presenting an API key of their tenant, and the resulting access token is
+// an ordinary agent token scoped to the connections they chose.
+//
+// Authorization codes are short-lived and kept in memory only.
+type Handler struct {
+	repo       *Repository
+	tokenRepo  *token.Repository
+	connRepo   *connection_data.Repository
+	apiKeyRepo *apikey.Repository
+	signer     *signing.KeyManager
+	cfg        Config
+	metadata   http.Handler
+
+	mu    sync.Mutex
+	codes map[string]*authorizationCode
+}
+
+func NewOAuthHandler(
+	repo *Repository,
+	tokenRepo *token.Repository,
+	connRepo *connection_data.Repository,
+	apiKeyRepo *apikey.Repository,
+	signer *signing.KeyManager,
+	cfg Config,
+) *Handler {
+	cfg.PublicURL = strings.TrimRight(cfg.PublicURL, "/")
+	if cfg.TokenTTL <= 0 {
+		cfg.TokenTTL = DefaultTokenTTL
+	}
+
+	return &Handler{
+		repo:       repo,
+		tokenRepo:  tokenRepo,
+		connRepo:   connRepo,
+		apiKeyRepo: apiKeyRepo,
+		signer:     signer,
+		cfg:        cfg,
+		metadata: auth.ProtectedResourceMetadataHandler(&oauthex.ProtectedResourceMetadata{
+			Resource:               ResourceURL(cfg.PublicURL),
+			AuthorizationServers:   append([]string{cfg.PublicURL}, cfg.AuthorizationServers...),
+			ScopesSupported:        GetScopes(),
+			BearerMethodsSupported: []string{"header"},
+			ResourceName:           "PinoQL MCP",
+		}),
+		codes: map[string]*authorizationCode{},
+	}
+}
+
+func (h *Handler) ProtectedResourceMetadata(c *gin.Context) {
+	h.metadata.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *Handler) AuthorizationServerMetadata(c *gin.Context) {
+	c.JSON(http.StatusOK, &AuthorizationServerMetadata{
+		Issuer:                            h.cfg.PublicURL,
+		AuthorizationEndpoint:             h.cfg.PublicURL + "/oauth/authorize",
+		TokenEndpoint:                     h.cfg.PublicURL + "/oauth/token",
+		JWKSURI:                           h.cfg.PublicURL + "/.well-known/jwks.json",
+		RegistrationEndpoint:              h.cfg.PublicURL + "/oauth/register",
+		ScopesSupported:                   GetScopes(),
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		TokenEndpointAuthMethodsSupported: []string{"none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	})
+}
+
+// RegisterClient implements dynamic client registration (RFC 7591). Only
+// public clients are supported; they authenticate through PKCE instead of a
+// client secret.
+func (h *Handler) RegisterClient(c *gin.Context) {
+	var req ClientRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": err.Error()})
+		return
+	}
+
+	if len(req.RedirectURIs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_redirect_uri", "error_description": "redirect_uris is required"})
+		return
+	}
+	for _, redirectURI := range req.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_redirect_uri", "error_description": "redirect URIs must use https or a loopback address: " + redirectURI})
+			return
+		}
+	}
+	if req.TokenEndpointAuthMethod != "" && req.TokenEndpointAuthMethod != "none" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": "only public clients are supported"})
+		return
+	}
+	for _, grantType := range req.GrantTypes {
+		if grantType != "authorization_code" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": "unsupported grant type: " + grantType})
+			return
+		}
+	}
+
+	client, err := h.repo.RegisterClient(req.ClientName, req.RedirectURIs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	req.TokenEndpointAuthMethod = "none"
+	req.GrantTypes = []string{"authorization_code"}
+	req.ResponseTypes = []string{"code"}
+
+	c.JSON(http.StatusCreated, &ClientRegistrationResponse{
+		ClientRegistrationRequest: req,
+		ClientID:                  client.ClientID,
+		ClientIDIssuedAt:          client.CreatedAt.Unix(),
+	})
+}
+
+// Authorize renders the consent page for a valid authorization request.
+func (h *Handler) Authorize(c *gin.Context) {
+	req, client, ok := h.authorizeRequest(c, c.Request.URL.Query())
+	if !ok {
+		return
+	}
+
+	h.renderConsent(c, http.StatusOK, req, client, "")
+}
+
+// Approve handles the consent form. On approval it verifies the API key and
+// the chosen connections, then redirects back to the client with a code.
+func (h *Handler) Approve(c *gin.Context) {
+	if err := c.Request.ParseForm(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		return
+	}
+
+	req, client, ok := h.authorizeRequest(c, c.Request.PostForm)
+	if !ok {
+		return
+	}
+
+	if c.PostForm("action") != "approve" {
+		redirectWithError(c, req, "access_denied", "the user denied the request")
+		return
+	}
+
+	key, err := h.apiKeyRepo.VerifyKey(c.PostForm("api_key"))
+	if err != nil || !key.HasScope(apikey.ScopeTokens) {
+		h.renderConsent(c, http.StatusUnauthorized, req, client, "Invalid API key, or the key lacks the tokens scope.")
+		return
+	}
+
+	var connectionIDs []string
+	for _, connID := range strings.Split(c.PostForm("connection_ids"), ",") {
+		connID = strings.TrimSpace(connID)
+		if connID == "" || slices.Contains(connectionIDs, connID) {
+			continue
+		}
+		if _, err := h.connRepo.GetConnectionByID(key.TenantID, connID); err != nil {
+			h.renderConsent(c, http.StatusForbidden, req, client, "Access denied to connection: "+connID)
+			return
+		}
+		connectionIDs = append(connectionIDs, connID)
+	}
+	if len(connectionIDs) == 0 {
+		h.renderConsent(c, http.StatusBadRequest, req, client, "Choose at least one connection.")
+		return
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		redirectWithError(c, req, "server_error", "")
+		return
+	}
+
+	req.TenantID = key.TenantID
+	req.ConnectionIDs = connectionIDs
+	req.ExpiresAt = time.Now().Add(codeTTL)
+	h.storeCode(code, req)
+
+	redirectTo(c, req, url.Values{"code": {code}})
+}
+
+// Token redeems an authorization code for an access token.
+func (h *Handler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	if c.PostForm("grant_type") != "authorization_code" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_grant_type"})
+		return
+	}
+
+	req, ok := h.takeCode(c.PostForm("code"))
+	if !ok ||
+		req.ClientID != c.PostForm("client_id") ||
+		req.RedirectURI != c.PostForm("redirect_uri") ||
+		!verifyCodeChallenge(req.CodeChallenge, c.PostForm("code_verifier")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant"})
+		return
+	}
+
+	if resource := c.PostForm("resource"); resource != "" && resource != ResourceURL(h.cfg.PublicURL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target"})
+		return
+	}
+
+	issued, err := token.Issue(
+		h.signer,
+		h.tokenRepo,
+		req.TenantID,
+		req.ConnectionIDs,
+		permissionsForScopes(req.Scopes),
+		h.cfg.TokenTTL,
+		ResourceURL(h.cfg.PublicURL),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": issued.Token,
+		"token_type":   "Bearer",
+		"expires_in":   int(h.cfg.TokenTTL.Seconds()),
+		"scope":        strings.Join(req.Scopes, " "),
+	})
+}
+
+// authorizeRequest validates the parameters of an authorization request.
+// Errors about the client or redirect URI are shown to the user, since
+// redirecting to an unverified URI would make us an open redirector; all
+// other errors are sent back to the client.
+func (h *Handler) authorizeRequest(c *gin.Context, params url.Values) (*authorizationCode, *Client, bool) {
+	client, err := h.repo.GetClient(params.Get("client_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client"})
+		return nil, nil, false
+	}
+
+	redirectURI := params.Get("redirect_uri")
+	if !client.AllowsRedirectURI(redirectURI) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "unregistered redirect_uri"})
+		return nil, nil, false
+	}
+
+	req := &authorizationCode{
+		ClientID:      client.ClientID,
+		RedirectURI:   redirectURI,
+		CodeChallenge: params.Get("code_challenge"),
+		State:         params.Get("state"),
+	}
+
+	if params.Get("response_type") != "code" {
+		redirectWithError(c, req, "unsupported_response_type", "")
+		return nil, nil, false
+	}
+	if req.CodeChallenge == "" || params.Get("code_challenge_method") != "S256" {
+		redirectWithError(c, req, "invalid_request", "PKCE with S256 is required")
+		return nil, nil, false
+	}
+	if resource := params.Get("resource"); resource != "" && resource != ResourceURL(h.cfg.PublicURL) {
+		redirectWithError(c, req, "invalid_target", "")
+		return nil, nil, false
+	}
+
+	scopes, ok := parseScopes(params.Get("scope"))
+	if !ok {
+		redirectWithError(c, req, "invalid_scope", "")
+		return nil, nil, false
+	}
+	req.Scopes = scopes
+
+	return req, client, true
+}
+
+func (h *Handler) renderConsent(c *gin.Context, status int, req *authorizationCode, client *Client, message string) {
+	clientName := client.ClientID
+	if client.ClientName != nil && *client.ClientName != "" {
+		clientName = *client.ClientName
+	}
+
+	page := consentPage{
+		ClientName: clientName,
+		Scopes:     req.Scopes,
+		Params: map[string]string{
+			"client_id":             req.ClientID,
+			"redirect_uri":          req.RedirectURI,
+			"response_type":         "code",
+			"code_challenge":        req.CodeChallenge,
+			"code_challenge_method": "S256",
+			"scope":                 strings.Join(req.Scopes, " "),
+			"state":                 req.State,
+		},
+		Error: message,
+	}
+
+	// The consent page must not be framed, or a malicious site could trick
+	// users into approving requests.
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "frame-ancestors 'none'")
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := consentTemplate.Execute(c.Writer, page); err != nil {
+		_ = c.Error(fmt.Errorf("failed to render consent page: %w", err))
+	}
+}
+
+func (h *Handler) storeCode(code string, req *authorizationCode) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for key, stored := range h.codes {
+		if now.After(stored.ExpiresAt) {
+			delete(h.codes, key)
+		}
+	}
+
+	h.codes[code] = req
+}
+
+// takeCode returns the request behind code and consumes it, so that a code
+// can be redeemed at most once.
+func (h *Handler) takeCode(code string) (*authorizationCode, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	req, ok := h.codes[code]
+	if !ok {
+		return nil, false
+	}
+	delete(h.codes, code)
+
+	if time.Now().After(req.ExpiresAt) {
+		return nil, false
+	}
+	return req, true
+}
+
+func redirectWithError(c *gin.Context, req *authorizationCode, code, description string) {
+	params := url.Values{"error": {code}}
+	if description != "" {
+		params.Set("error_description", description)
+	}
+	redirectTo(c, req, params)
+}
+
+func redirectTo(c *gin.Context, req *authorizationCode, params url.Values) {
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	target, _ := url.Parse(req.RedirectURI)
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+
+	c.Redirect(http.StatusFound, target.String())
+}
+
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// validRedirectURI accepts https URIs and, for native clients, http URIs on
+// a loopback address.
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Fragment != "" || u.Host == "" {
+		return false
+	}
+
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	default:
+		return false
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,106 @@
+package oauth
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+)
+
+const (
+	ScopeRead  = "pinoql:read"
+	ScopeWrite = "pinoql:write"
+)
+
+func GetScopes() []string {
+	return []string{ScopeRead, ScopeWrite}
+}
+
+type Client struct {
+	ClientID     string    `json:"client_id" db:"client_id"`
+	ClientName   *string   `json:"client_name,omitempty" db:"client_name"`
+	RedirectURIs string    `json:"redirect_uris" db:"redirect_uris"` // JSON array string
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+func (Client) TableName() string {
+	return "oauth_clients"
+}
+
+// AllowsRedirectURI reports whether redirectURI was registered for the
+// client. Registered URIs are compared exactly.
+func (c *Client) AllowsRedirectURI(redirectURI string) bool {
+	var redirectURIs []string
+	if err := json.Unmarshal([]byte(c.RedirectURIs), &redirectURIs); err != nil {
+		return false
+	}
+	return slices.Contains(redirectURIs, redirectURI)
+}
+
+// authorizationCode is what an approved authorization request turns into
+// until the client redeems it at the token endpoint.
+type authorizationCode struct {
+	ClientID      string
+	RedirectURI   string
+	CodeChallenge string
+	State         string
+	Scopes        []string
+	TenantID      string
+	ConnectionIDs []string
+	ExpiresAt     time.Time
+}
+
+// parseScopes splits a space-separated scope parameter. An empty parameter
+// requests read access only.
+func parseScopes(scope string) ([]string, bool) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return []string{ScopeRead}, true
+	}
+	for _, s := range scopes {
+		if !slices.Contains(GetScopes(), s) {
+			return nil, false
+		}
+	}
+	return scopes, true
+}
+
+func permissionsForScopes(scopes []string) claims.ConnectionPermissions {
+	if slices.Contains(scopes, ScopeWrite) {
+		return claims.DefaultReadWritePermissions()
+	}
+	return claims.DefaultReadOnlyPermissions()
+}
+
+// AuthorizationServerMetadata is the subset of RFC 8414 metadata the
+// built-in authorization server publishes.
+type AuthorizationServerMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	RegistrationEndpoint              string   `json:"registration_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+// ClientRegistrationRequest holds the RFC 7591 client metadata we act on;
+// other fields are accepted and ignored.
+type ClientRegistrationRequest struct {
+	RedirectURIs            []string `json:"redirect_uris"`
+	ClientName              string   `json:"client_name,omitempty"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method,omitempty"`
+	GrantTypes              []string `json:"grant_types,omitempty"`
+	ResponseTypes           []string `json:"response_types,omitempty"`
+}
+
+type ClientRegistrationResponse struct {
+	ClientRegistrationRequest
+	ClientID         string `json:"client_id"`
+	ClientIDIssuedAt int64  `json:"client_id_issued_at"`
+}
@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewOAuthRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) RegisterClient(clientName string, redirectURIs []string) (*Client, error) {
+	redirectURIsJSON, err := json.Marshal(redirectURIs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize redirect URIs: %w", err)
+	}
+
+	var name *string
+	if clientName != "" {
+		name = &clientName
+	}
+
+	clientID := "client_" + uuid.New().String()
+
+	query := `
+		INSERT INTO oauth_clients (client_id, client_name, redirect_uris)
+		VALUES (?, ?, ?)
+	`
+
+	_, err = r.db.Exec(query, clientID, name, string(redirectURIsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert OAuth client: %w", err)
+	}
+
+	return r.GetClient(clientID)
+}
+
+func (r *Repository) GetClient(clientID string) (*Client, error) {
+	var client Client
+
+	query := `
+		SELECT client_id, client_name, redirect_uris, created_at
+		FROM oauth_clients
+		WHERE client_id = ?
+	`
+
+	err := r.db.Get(&client, query, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("client not found")
+		}
+		return nil, fmt.Errorf("failed to get OAuth client: %w", err)
+	}
+
+	return &client, nil
+}
@@ -0,0 +1,169 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+)
+
+// MappingConfig describes how IdP claims become PinoQL claims. Claim names
+// may use dots to reach into nested objects, e.g. "realm_access.roles".
+//
+// The tenant comes from TenantIDClaim, or TenantID when the IdP has no such
+// claim. Connections and permissions come from the optional
+// ConnectionIDsClaim and PermissionsClaim plus every matching rule.
+type MappingConfig struct {
+	TenantIDClaim      string        `json:"tenant_id_claim,omitempty"`
+	TenantID           string        `json:"tenant_id,omitempty"`
+	ConnectionIDsClaim string        `json:"connection_ids_claim,omitempty"`
+	PermissionsClaim   string        `json:"permissions_claim,omitempty"`
+	Rules              []MappingRule `json:"rules"`
+}
+
+// MappingRule grants ConnectionIDs and Permissions when Claim equals Value,
+// or contains it when the claim is a list (typically groups or roles).
+type MappingRule struct {
+	Claim         string                        `json:"claim"`
+	Value         string                        `json:"value"`
+	ConnectionIDs []string                      `json:"connection_ids"`
+	Permissions   *claims.ConnectionPermissions `json:"permissions,omitempty"`
+}
+
+func LoadMappingFile(path string) (*MappingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claim mapping: %w", err)
+	}
+
+	var cfg MappingConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid claim mapping %s: %w", path, err)
+	}
+
+	if cfg.TenantIDClaim == "" && cfg.TenantID == "" {
+		return nil, fmt.Errorf("claim mapping must set tenant_id_claim or tenant_id")
+	}
+
+	return &cfg, nil
+}
+
+// apply maps IdP claims to a tenant, connections and permissions. When
+// several sources grant permissions the most permissive combination wins,
+// since each of them was granted on its own.
+func (cfg *MappingConfig) apply(idpClaims map[string]any) (string, []string, claims.ConnectionPermissions, error) {
+	tenantID := cfg.TenantID
+	if cfg.TenantIDClaim != "" {
+		value, _ := lookup(idpClaims, cfg.TenantIDClaim).(string)
+		if value == "" {
+			return "", nil, claims.ConnectionPermissions{}, fmt.Errorf("token has no %s claim", cfg.TenantIDClaim)
+		}
+		tenantID = value
+	}
+
+	var connectionIDs []string
+	var granted []claims.ConnectionPermissions
+
+	if cfg.ConnectionIDsClaim != "" {
+		connectionIDs = append(connectionIDs, stringValues(lookup(idpClaims, cfg.ConnectionIDsClaim))...)
+	}
+
+	if cfg.PermissionsClaim != "" {
+		if raw := lookup(idpClaims, cfg.PermissionsClaim); raw != nil {
+			data, err := json.Marshal(raw)
+			if err != nil {
+				return "", nil, claims.ConnectionPermissions{}, fmt.Errorf("invalid %s claim: %w", cfg.PermissionsClaim, err)
+			}
+			var permissions claims.ConnectionPermissions
+			if err := json.Unmarshal(data, &permissions); err != nil {
+				return "", nil, claims.ConnectionPermissions{}, fmt.Errorf("invalid %s claim: %w", cfg.PermissionsClaim, err)
+			}
+			granted = append(granted, permissions)
+		}
+	}
+
+	for _, rule := range cfg.Rules {
+		if !slices.Contains(stringValues(lookup(idpClaims, rule.Claim)), rule.Value) {
+			continue
+		}
+		connectionIDs = append(connectionIDs, rule.ConnectionIDs...)
+		if rule.Permissions != nil {
+			granted = append(granted, *rule.Permissions)
+		}
+	}
+
+	slices.Sort(connectionIDs)
+	connectionIDs = slices.Compact(connectionIDs)
+
+	if len(connectionIDs) == 0 {
+		return "", nil, claims.ConnectionPermissions{}, fmt.Errorf("token grants no connections")
+	}
+
+	permissions := claims.DefaultReadOnlyPermissions()
+	if len(granted) > 0 {
+		permissions = mergePermissions(granted)
+	}
+
+	return tenantID, connectionIDs, permissions, nil
+}
+
+func mergePermissions(granted []claims.ConnectionPermissions) claims.ConnectionPermissions {
+	merged := granted[0]
+	merged.AllowedOps = slices.Clone(merged.AllowedOps)
+
+	for _, p := range granted[1:] {
+		merged.Read = merged.Read || p.Read
+		merged.Write = merged.Write || p.Write
+		merged.Schema = merged.Schema || p.Schema
+		merged.DDL = merged.DDL || p.DDL
+		merged.MaxRows = mostPermissiveLimit(merged.MaxRows, p.MaxRows)
+		merged.QueryTimeoutSeconds = mostPermissiveLimit(merged.QueryTimeoutSeconds, p.QueryTimeoutSeconds)
+		for _, op := range p.AllowedOps {
+			if !slices.Contains(merged.AllowedOps, op) {
+				merged.AllowedOps = append(merged.AllowedOps, op)
+			}
+		}
+	}
+
+	return merged
+}
+
+// mostPermissiveLimit combines two limits where 0 means unlimited.
+func mostPermissiveLimit(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return max(a, b)
+}
+
+func lookup(values map[string]any, path string) any {
+	var current any = values
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = object[part]
+	}
+	return current
+}
+
+func stringValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,192 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultJWKSCacheTTL = 15 * time.Minute
+	jwksMinRefresh      = 30 * time.Second
+	httpTimeout         = 10 * time.Second
+	// Failed discoveries are retried after a backoff doubling from
+	// discoveryMinBackoff up to discoveryMaxBackoff.
+	discoveryMinBackoff = 5 * time.Second
+	discoveryMaxBackoff = 5 * time.Minute
+)
+
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+type Config struct {
+	Issuer   string
+	Audience string
+	Mapping  *MappingConfig
+	// JWKSCacheTTL bounds how long fetched keys are trusted without
+	// refetching them.
+	JWKSCacheTTL time.Duration
+}
+
+// Verifier accepts tokens issued by an external OpenID Connect provider and
+// maps them to PinoQL claims. Discovery happens lazily on first use, so a
+// provider that is briefly down does not prevent startup.
+type Verifier struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.Mutex
+	keys *keySet
+	// discoveryErr is the last discovery failure. It is returned without
+	// contacting the provider again until retryAt.
+	discoveryErr error
+	retryAt      time.Time
+	backoff      time.Duration
+
+	// discoverMu serializes discoveries so concurrent requests share one.
+	discoverMu sync.Mutex
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, fmt.Errorf("OIDC issuer and audience are required")
+	}
+	if cfg.Mapping == nil {
+		return nil, fmt.Errorf("OIDC claim mapping is required")
+	}
+	if cfg.JWKSCacheTTL <= 0 {
+		cfg.JWKSCacheTTL = defaultJWKSCacheTTL
+	}
+
+	return &Verifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: httpTimeout},
+	}, nil
+}
+
+// Handles reports whether tokenString claims to come from this provider. It
+// does not verify anything; callers use it to pick a verifier.
+func (v *Verifier) Handles(tokenString string) bool {
+	var registered jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, &registered); err != nil {
+		return false
+	}
+	return registered.Issuer == v.cfg.Issuer
+}
+
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*claims.PinoQLClaims, error) {
+	keys, err := v.keySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idpClaims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, idpClaims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keys.key(ctx, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(v.cfg.Issuer),
+		jwt.WithAudience(v.cfg.Audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	tenantID, connectionIDs, permissions, err := v.cfg.Mapping.apply(idpClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	registered := jwt.RegisteredClaims{Issuer: v.cfg.Issuer}
+	registered.Subject, _ = idpClaims.GetSubject()
+	registered.Audience, _ = idpClaims.GetAudience()
+	registered.ExpiresAt, _ = idpClaims.GetExpirationTime()
+	registered.IssuedAt, _ = idpClaims.GetIssuedAt()
+	registered.NotBefore, _ = idpClaims.GetNotBefore()
+	registered.ID, _ = idpClaims["jti"].(string)
+
+	return &claims.PinoQLClaims{
+		RegisteredClaims: registered,
+		TenantID:         tenantID,
+		ConnectionIDs:    connectionIDs,
+		Permissions:      permissions,
+	}, nil
+}
+
+func (v *Verifier) keySet(ctx context.Context) (*keySet, error) {
+	if keys, err := v.cachedKeySet(); keys != nil || err != nil {
+		return keys, err
+	}
+
+	v.discoverMu.Lock()
+	defer v.discoverMu.Unlock()
+
+	// Another request may have finished discovery while we waited.
+	if keys, err := v.cachedKeySet(); keys != nil || err != nil {
+		return keys, err
+	}
+
+	// The result is shared with every request, so one that goes away must
+	// not abort it.
+	keys, err := v.discover(context.WithoutCancel(ctx))
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err != nil {
+		v.backoff = min(max(2*v.backoff, discoveryMinBackoff), discoveryMaxBackoff)
+		v.discoveryErr = err
+		v.retryAt = time.Now().Add(v.backoff)
+		return nil, err
+	}
+
+	v.keys = keys
+	v.discoveryErr = nil
+	return keys, nil
+}
+
+// cachedKeySet returns the discovered key set, or the last discovery error
+// while retries are backing off. Both are nil when discovery should run.
+func (v *Verifier) cachedKeySet() (*keySet, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.keys != nil {
+		return v.keys, nil
+	}
+	if v.discoveryErr != nil && time.Now().Before(v.retryAt) {
+		return nil, v.discoveryErr
+	}
+	return nil, nil
+}
+
+func (v *Verifier) discover(ctx context.Context) (*keySet, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	url := strings.TrimRight(v.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, v.client, url, &discovery); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if discovery.Issuer != v.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC discovery returned issuer %q, expected %q", discovery.Issuer, v.cfg.Issuer)
+	}
+	if discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document has no jwks_uri")
+	}
+
+	return &keySet{
+		client:  v.client,
+		url:     discovery.JWKSURI,
+		ttl:     v.cfg.JWKSCacheTTL,
+		minWait: jwksMinRefresh,
+	}, nil
+}
@@ -0,0 +1,341 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testAudience = "pinoql"
+
+// mockIdP serves an OpenID discovery document and a JWKS, counting requests
+// to each.
+type mockIdP struct {
+	server *httptest.Server
+
+	mu             sync.Mutex
+	keys           map[string]*ecdsa.PrivateKey
+	failDiscovery  bool
+	discoveryHits  int
+	jwksHits       int
+	jwksStatusCode int
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	idp := &mockIdP{keys: map[string]*ecdsa.PrivateKey{}}
+	idp.server = httptest.NewServer(http.HandlerFunc(idp.serve))
+	t.Cleanup(idp.server.Close)
+	idp.addKey(t, "key-1")
+	return idp
+}
+
+func (idp *mockIdP) issuer() string {
+	return idp.server.URL
+}
+
+func (idp *mockIdP) addKey(t *testing.T, kid string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keys[kid] = key
+}
+
+func (idp *mockIdP) hits() (discovery, jwks int) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	return idp.discoveryHits, idp.jwksHits
+}
+
+func (idp *mockIdP) serve(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		idp.discoveryHits++
+		if idp.failDiscovery {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   idp.issuer(),
+			"jwks_uri": idp.issuer() + "/jwks",
+		})
+
+	case "/jwks":
+		idp.jwksHits++
+		if idp.jwksStatusCode != 0 {
+			w.WriteHeader(idp.jwksStatusCode)
+			return
+		}
+		keys := []jsonWebKey{}
+		for kid, key := range idp.keys {
+			keys = append(keys, jsonWebKey{
+				KTY: "EC",
+				KID: kid,
+				Use: "sig",
+				Crv: "P-256",
+				X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+				Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// sign issues a token signed with kid, filling in valid registered claims
+// that values may override.
+func (idp *mockIdP) sign(t *testing.T, kid string, values jwt.MapClaims) string {
+	t.Helper()
+
+	idp.mu.Lock()
+	key := idp.keys[kid]
+	idp.mu.Unlock()
+
+	tokenClaims := jwt.MapClaims{
+		"iss":    idp.issuer(),
+		"aud":    testAudience,
+		"sub":    "alice",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"iat":    time.Now().Unix(),
+		"jti":    "token-1",
+		"tenant": "t1",
+		"groups": []string{"analysts"},
+	}
+	for name, value := range values {
+		tokenClaims[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, tokenClaims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+func newTestVerifier(t *testing.T, idp *mockIdP) *Verifier {
+	t.Helper()
+
+	verifier, err := NewVerifier(Config{
+		Issuer:   idp.issuer(),
+		Audience: testAudience,
+		Mapping: &MappingConfig{
+			TenantIDClaim: "tenant",
+			Rules: []MappingRule{{
+				Claim:         "groups",
+				Value:         "analysts",
+				ConnectionIDs: []string{"conn_a"},
+				Permissions:   &claims.ConnectionPermissions{Read: true, Schema: true, AllowedOps: []string{"SELECT"}},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return verifier
+}
+
+func TestVerify(t *testing.T) {
+	idp := newMockIdP(t)
+	verifier := newTestVerifier(t, idp)
+	ctx := context.Background()
+
+	token := idp.sign(t, "key-1", nil)
+	if !verifier.Handles(token) {
+		t.Fatalf("Handles returned false for the provider's token")
+	}
+
+	got, err := verifier.Verify(ctx, token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got.TenantID != "t1" || !slices.Equal(got.ConnectionIDs, []string{"conn_a"}) || got.Subject != "alice" || got.ID != "token-1" {
+		t.Fatalf("Verify = %+v", got)
+	}
+	if !got.Permissions.Read || got.Permissions.Write || !slices.Equal(got.Permissions.AllowedOps, []string{"SELECT"}) {
+		t.Fatalf("Permissions = %+v", got.Permissions)
+	}
+
+	rejected := map[string]string{
+		"wrong audience": idp.sign(t, "key-1", jwt.MapClaims{"aud": "other"}),
+		"expired":        idp.sign(t, "key-1", jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}),
+		"no expiration":  idp.sign(t, "key-1", jwt.MapClaims{"exp": nil}),
+		"no connections": idp.sign(t, "key-1", jwt.MapClaims{"groups": []string{"guests"}}),
+		"no tenant":      idp.sign(t, "key-1", jwt.MapClaims{"tenant": nil}),
+		"tampered":       token[:len(token)-4] + "AAAA",
+	}
+	for name, token := range rejected {
+		if _, err := verifier.Verify(ctx, token); err == nil {
+			t.Errorf("%s: Verify accepted the token", name)
+		}
+	}
+
+	other := idp.sign(t, "key-1", jwt.MapClaims{"iss": "https://other.example"})
+	if verifier.Handles(other) {
+		t.Fatalf("Handles returned true for another issuer")
+	}
+}
+
+func TestVerifyRefetchesKeysForUnknownKid(t *testing.T) {
+	idp := newMockIdP(t)
+	verifier := newTestVerifier(t, idp)
+	ctx := context.Background()
+
+	if _, err := verifier.Verify(ctx, idp.sign(t, "key-1", nil)); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	// The provider rotates its key; the unknown kid is refetched at most
+	// once per minWait.
+	idp.addKey(t, "key-2")
+	rotated := idp.sign(t, "key-2", nil)
+	if _, err := verifier.Verify(ctx, rotated); err == nil {
+		t.Fatalf("Verify accepted an unknown kid before minWait elapsed")
+	}
+	if _, jwks := idp.hits(); jwks != 1 {
+		t.Fatalf("JWKS fetched %d times within minWait, want 1", jwks)
+	}
+
+	verifier.keys.mu.Lock()
+	verifier.keys.attemptedAt = time.Time{}
+	verifier.keys.mu.Unlock()
+
+	if _, err := verifier.Verify(ctx, rotated); err != nil {
+		t.Fatalf("Verify after refetch: %v", err)
+	}
+	if _, jwks := idp.hits(); jwks != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", jwks)
+	}
+}
+
+func TestVerifyServesCachedKeysWhileJWKSFails(t *testing.T) {
+	idp := newMockIdP(t)
+	verifier := newTestVerifier(t, idp)
+	ctx := context.Background()
+
+	token := idp.sign(t, "key-1", nil)
+	if _, err := verifier.Verify(ctx, token); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	idp.mu.Lock()
+	idp.jwksStatusCode = http.StatusInternalServerError
+	idp.mu.Unlock()
+
+	// Expire the cache: the failed refresh keeps the known key, and is not
+	// retried before minWait.
+	verifier.keys.mu.Lock()
+	verifier.keys.fetchedAt = time.Time{}
+	verifier.keys.attemptedAt = time.Time{}
+	verifier.keys.mu.Unlock()
+
+	for range 3 {
+		if _, err := verifier.Verify(ctx, token); err != nil {
+			t.Fatalf("Verify with an unreachable JWKS: %v", err)
+		}
+	}
+	if _, jwks := idp.hits(); jwks != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", jwks)
+	}
+
+	_, err := verifier.Verify(ctx, idp.sign(t, "key-1", jwt.MapClaims{"jti": "token-2"}))
+	if err != nil {
+		t.Fatalf("Verify of another token: %v", err)
+	}
+}
+
+func TestDiscoveryFailureBacksOff(t *testing.T) {
+	idp := newMockIdP(t)
+	verifier := newTestVerifier(t, idp)
+	ctx := context.Background()
+	token := idp.sign(t, "key-1", nil)
+
+	idp.mu.Lock()
+	idp.failDiscovery = true
+	idp.mu.Unlock()
+
+	for range 3 {
+		if _, err := verifier.Verify(ctx, token); err == nil || !strings.Contains(err.Error(), "OIDC discovery failed") {
+			t.Fatalf("Verify error = %v, want discovery failure", err)
+		}
+	}
+	if discovery, _ := idp.hits(); discovery != 1 {
+		t.Fatalf("discovery attempted %d times during backoff, want 1", discovery)
+	}
+
+	// A second failure doubles the backoff.
+	verifier.mu.Lock()
+	verifier.retryAt = time.Time{}
+	verifier.mu.Unlock()
+	_, _ = verifier.Verify(ctx, token)
+
+	verifier.mu.Lock()
+	backoff := verifier.backoff
+	verifier.retryAt = time.Time{}
+	verifier.mu.Unlock()
+	if backoff != 2*discoveryMinBackoff {
+		t.Fatalf("backoff = %v, want %v", backoff, 2*discoveryMinBackoff)
+	}
+
+	idp.mu.Lock()
+	idp.failDiscovery = false
+	idp.mu.Unlock()
+
+	if _, err := verifier.Verify(ctx, token); err != nil {
+		t.Fatalf("Verify after the provider recovered: %v", err)
+	}
+	if discovery, _ := idp.hits(); discovery != 3 {
+		t.Fatalf("discovery attempted %d times, want 3", discovery)
+	}
+}
+
+func TestConcurrentVerifyFetchesOnce(t *testing.T) {
+	idp := newMockIdP(t)
+	verifier := newTestVerifier(t, idp)
+	token := idp.sign(t, "key-1", nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for range 16 {
+		wg.Go(func() {
+			if _, err := verifier.Verify(context.Background(), token); err != nil {
+				errs <- err
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Verify: %v", err)
+	}
+	if discovery, jwks := idp.hits(); discovery != 1 || jwks != 1 {
+		t.Fatalf("discovery = %d, JWKS = %d, want one fetch of each", discovery, jwks)
+	}
+}
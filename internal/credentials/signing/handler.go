@@ -0,0 +1,21 @@
+package signing
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	manager *KeyManager
+}
+
+func NewSigningHandler(manager *KeyManager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// JWKS serves the public verification keys at /.well-known/jwks.json.
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.manager.JWKS())
+}
@@ -0,0 +1,364 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultRotationPeriod = 30 * 24 * time.Hour
+	// DefaultRetention must exceed the longest token TTL we issue, so a key
+	// keeps verifying every token it signed until they have all expired.
+	DefaultRetention = 31 * 24 * time.Hour
+
+	rotationCheckInterval = time.Hour
+	// reloadInterval throttles reloads triggered by unknown kids, which is
+	// how an instance learns about keys rotated by another one.
+	reloadInterval = 10 * time.Second
+)
+
+type Config struct {
+	Algorithm string
+	// HMACSecret signs tokens in HS256 mode. With an asymmetric algorithm it
+	// is only used to verify HS256 tokens issued before the switch.
+	HMACSecret     string
+	RotationPeriod time.Duration
+	Retention      time.Duration
+}
+
+// KeyManager signs PinoQL tokens and resolves the keys to verify them. With
+// an asymmetric algorithm it keeps one active key plus the retired keys that
+// may still have unexpired tokens, rotating on a schedule.
+type KeyManager struct {
+	repo *Repository
+	cfg  Config
+
+	mu         sync.RWMutex
+	active     *Key
+	keys       map[string]*Key
+	lastReload time.Time
+}
+
+func NewKeyManager(repo *Repository, cfg Config) (*KeyManager, error) {
+	if !slices.Contains(GetAlgorithms(), cfg.Algorithm) {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+	if cfg.Algorithm == AlgHS256 && cfg.HMACSecret == "" {
+		return nil, fmt.Errorf("HS256 signing requires a secret")
+	}
+	if cfg.RotationPeriod <= 0 {
+		cfg.RotationPeriod = DefaultRotationPeriod
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+
+	m := &KeyManager{
+		repo: repo,
+		cfg:  cfg,
+		keys: map[string]*Key{},
+	}
+
+	if cfg.Algorithm == AlgHS256 {
+		return m, nil
+	}
+
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	if err := m.rotateIfDue(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Sign signs claims with the active key, setting its kid header.
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	if m.cfg.Algorithm == AlgHS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(m.cfg.HMACSecret))
+	}
+
+	m.mu.RLock()
+	active := m.active
+	m.mu.RUnlock()
+
+	if active == nil {
+		return "", fmt.Errorf("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(active.Algorithm), claims)
+	token.Header["kid"] = active.KID
+	return token.SignedString(active.PrivateKey)
+}
+
+// Keyfunc resolves the verification key of a token. Tokens carrying a kid
+// must be signed with exactly that key's algorithm; tokens without one are
+// only accepted as HS256 and only while a secret is configured.
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || m.cfg.HMACSecret == "" {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(m.cfg.HMACSecret), nil
+	}
+
+	key, ok := m.key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return key.PrivateKey.Public(), nil
+}
+
+// ParserOptions restricts parsing to the algorithms this manager can verify.
+func (m *KeyManager) ParserOptions() []jwt.ParserOption {
+	methods := []string{m.cfg.Algorithm}
+	if m.cfg.HMACSecret != "" && m.cfg.Algorithm != AlgHS256 {
+		methods = append(methods, AlgHS256)
+	}
+
+	m.mu.RLock()
+	for _, key := range m.keys {
+		if !slices.Contains(methods, key.Algorithm) {
+			methods = append(methods, key.Algorithm)
+		}
+	}
+	m.mu.RUnlock()
+
+	return []jwt.ParserOption{jwt.WithValidMethods(methods)}
+}
+
+// JWKS returns the public halves of every key that may still verify tokens.
+func (m *KeyManager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range m.keys {
+		jwk, err := publicJWK(key)
+		if err != nil {
+			log.Printf("Failed to encode signing key %s: %v", key.KID, err)
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	slices.SortFunc(set.Keys, func(a, b JWK) int {
+		return m.keys[b.KID].CreatedAt.Compare(m.keys[a.KID].CreatedAt)
+	})
+
+	return set
+}
+
+// StartRotation rotates the active key once it is older than the rotation
+// period and prunes keys past their retention, until ctx is done.
+func (m *KeyManager) StartRotation(ctx context.Context) {
+	if m.cfg.Algorithm == AlgHS256 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(rotationCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.reload(); err != nil {
+					log.Printf("Failed to reload signing keys: %v", err)
+					continue
+				}
+				if err := m.rotateIfDue(); err != nil {
+					log.Printf("Failed to rotate signing key: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Rotate generates a new active key and retires the current one, which keeps
+// verifying tokens until its retention ends.
+func (m *KeyManager) Rotate() error {
+	privateKey, err := generateKey(m.cfg.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	kid := uuid.New().String()
+
+	if err := m.repo.InsertKey(kid, m.cfg.Algorithm, der, now); err != nil {
+		return err
+	}
+	if err := m.repo.RetireKeysExcept(kid, now); err != nil {
+		return err
+	}
+
+	log.Printf("Rotated JWT signing key, new kid %s (%s)", kid, m.cfg.Algorithm)
+	return m.reload()
+}
+
+func (m *KeyManager) rotateIfDue() error {
+	m.mu.RLock()
+	active := m.active
+	m.mu.RUnlock()
+
+	if active == nil || active.Algorithm != m.cfg.Algorithm || time.Since(active.CreatedAt) >= m.cfg.RotationPeriod {
+		if err := m.Rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := m.repo.DeleteKeysRotatedBefore(time.Now().UTC().Add(-m.cfg.Retention)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *KeyManager) key(kid string) (*Key, bool) {
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	stale := time.Since(m.lastReload) > reloadInterval
+	m.mu.RUnlock()
+
+	if ok || !stale {
+		return key, ok
+	}
+
+	if err := m.reload(); err != nil {
+		log.Printf("Failed to reload signing keys: %v", err)
+		return nil, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok = m.keys[kid]
+	return key, ok
+}
+
+func (m *KeyManager) reload() error {
+	rows, ders, err := m.repo.ListKeys()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-m.cfg.Retention)
+	keys := map[string]*Key{}
+	var active *Key
+
+	for i, row := range rows {
+		if row.RotatedAt != nil && row.RotatedAt.Before(cutoff) {
+			continue
+		}
+
+		parsed, err := x509.ParsePKCS8PrivateKey(ders[i])
+		if err != nil {
+			return fmt.Errorf("failed to parse signing key %s: %w", row.KID, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("signing key %s is not a signer", row.KID)
+		}
+
+		key := &Key{
+			KID:        row.KID,
+			Algorithm:  row.Algorithm,
+			PrivateKey: signer,
+			CreatedAt:  row.CreatedAt,
+			RotatedAt:  row.RotatedAt,
+		}
+		keys[key.KID] = key
+
+		// Rows come newest first, so the first unrotated key wins should two
+		// instances rotate at the same time.
+		if active == nil && row.RotatedAt == nil {
+			active = key
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.active = active
+	m.lastReload = time.Now()
+	m.mu.Unlock()
+
+	return nil
+}
+
+func generateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, fmt.Errorf("cannot generate keys for algorithm %q", algorithm)
+	}
+}
+
+func publicJWK(key *Key) (JWK, error) {
+	jwk := JWK{KID: key.KID, Use: "sig", Alg: key.Algorithm}
+
+	switch publicKey := key.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KTY = "RSA"
+		jwk.N = base64URL(publicKey.N.Bytes())
+		jwk.E = base64URL(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := publicKey.ECDH()
+		if err != nil {
+			return JWK{}, err
+		}
+		// Uncompressed point: 0x04 || X || Y, each padded to the curve size.
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		jwk.KTY = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64URL(point[1 : 1+size])
+		jwk.Y = base64URL(point[1+size:])
+	case ed25519.PublicKey:
+		jwk.KTY = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64URL(publicKey)
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", publicKey)
+	}
+
+	return jwk, nil
+}
+
+func base64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,61 @@
+package signing
+
+import (
+	"crypto"
+	"time"
+)
+
+// Supported signing algorithms. HS256 keeps using the shared JWT_SECRET and
+// has no key set; the others use generated key pairs published as a JWKS.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+func GetAlgorithms() []string {
+	return []string{AlgHS256, AlgRS256, AlgES256, AlgEdDSA}
+}
+
+// SigningKeyRow is a signing key as stored: the PKCS#8 private key is
+// envelope-encrypted like DSNs are.
+type SigningKeyRow struct {
+	KID        string     `db:"kid"`
+	Algorithm  string     `db:"algorithm"`
+	PrivateKey string     `db:"private_key"`
+	DEK        string     `db:"dek"`
+	DEKKeyID   string     `db:"dek_key_id"`
+	CreatedAt  time.Time  `db:"created_at"`
+	RotatedAt  *time.Time `db:"rotated_at"`
+}
+
+// Key is a decrypted signing key. RotatedAt is nil while the key is the one
+// new tokens are signed with.
+type Key struct {
+	KID        string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	CreatedAt  time.Time
+	RotatedAt  *time.Time
+}
+
+type JWK struct {
+	KTY string `json:"kty"`
+	KID string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (SigningKeyRow) TableName() string {
+	return "signing_keys"
+}
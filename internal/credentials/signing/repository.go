@@ -0,0 +1,164 @@
+package signing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/crypto"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	db *sqlx.DB
+	cm *crypto.CryptoManager
+}
+
+func NewSigningKeyRepository(db *sqlx.DB, cm *crypto.CryptoManager) *Repository {
+	return &Repository{
+		db: db,
+		cm: cm,
+	}
+}
+
+func (r *Repository) InsertKey(kid, algorithm string, privateKeyDER []byte, createdAt time.Time) error {
+	envelope, err := r.cm.Encrypt(privateKeyDER, associatedData(kid))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+
+	query := `
+		INSERT INTO signing_keys (kid, algorithm, private_key, dek, dek_key_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = r.db.Exec(query, kid, algorithm, envelope.CiphertextHex, envelope.WrappedDEKHex, envelope.KeyID, createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert signing key: %w", err)
+	}
+
+	return nil
+}
+
+// ListKeys returns every stored key, newest first, with its private key
+// decrypted.
+func (r *Repository) ListKeys() ([]*SigningKeyRow, [][]byte, error) {
+	var rows []*SigningKeyRow
+
+	query := `
+		SELECT kid, algorithm, private_key, dek, dek_key_id, created_at, rotated_at
+		FROM signing_keys
+		ORDER BY created_at DESC
+	`
+
+	if err := r.db.Select(&rows, query); err != nil {
+		return nil, nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	ders := make([][]byte, 0, len(rows))
+	for _, row := range rows {
+		der, err := r.cm.Decrypt(&crypto.Envelope{
+			CiphertextHex: row.PrivateKey,
+			WrappedDEKHex: row.DEK,
+			KeyID:         row.DEKKeyID,
+		}, associatedData(row.KID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decrypt signing key %s: %w", row.KID, err)
+		}
+		ders = append(ders, der)
+	}
+
+	return rows, ders, nil
+}
+
+// RetireKeysExcept marks every active key other than kid as rotated.
+func (r *Repository) RetireKeysExcept(kid string, rotatedAt time.Time) error {
+	query := `
+		UPDATE signing_keys
+		SET rotated_at = ?
+		WHERE kid != ? AND rotated_at IS NULL
+	`
+
+	if _, err := r.db.Exec(query, rotatedAt, kid); err != nil {
+		return fmt.Errorf("failed to retire signing keys: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteKeysRotatedBefore removes keys that no longer verify any unexpired
+// token.
+func (r *Repository) DeleteKeysRotatedBefore(cutoff time.Time) (int64, error) {
+	query := `DELETE FROM signing_keys WHERE rotated_at IS NOT NULL AND rotated_at < ?`
+
+	result, err := r.db.Exec(query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete signing keys: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// RewrapDEKs re-wraps the DEKs protecting signing keys under the active
+// master key, mirroring connection_data.Repository.RewrapDEKs.
+func (r *Repository) RewrapDEKs() (int, error) {
+	var rows []SigningKeyRow
+
+	query := `
+		SELECT kid, private_key, dek, dek_key_id
+		FROM signing_keys
+		WHERE dek_key_id != ?
+	`
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sqlx.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	err = tx.Select(&rows, query, r.cm.ActiveKeyID())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list signing keys to rewrap: %w", err)
+	}
+
+	rewrapped := 0
+	for _, row := range rows {
+		envelope, err := r.cm.RewrapDEK(&crypto.Envelope{
+			CiphertextHex: row.PrivateKey,
+			WrappedDEKHex: row.DEK,
+			KeyID:         row.DEKKeyID,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to rewrap DEK of signing key %s: %w", row.KID, err)
+		}
+
+		result, err := tx.Exec(
+			`UPDATE signing_keys SET dek = ?, dek_key_id = ? WHERE kid = ? AND dek = ?`,
+			envelope.WrappedDEKHex, envelope.KeyID, row.KID, row.DEK,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update DEK of signing key %s: %w", row.KID, err)
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		rewrapped += int(updated)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rewrapped DEKs: %w", err)
+	}
+
+	return rewrapped, nil
+}
+
+func associatedData(kid string) []byte {
+	return []byte("pinoql:signing_keys:" + kid)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+var ErrTenantNotFound = errors.New("tenant not found")
+
 type Repository struct {
 	db *sqlx.DB
 }
@@ -42,7 +44,7 @@ func (r *Repository) GetTenantByID(id string) (*TenantQuery, error) {
 	err := r.db.Get(&tenant, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("tenant not found")
+			return nil, ErrTenantNotFound
 		}
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
@@ -88,7 +90,15 @@ func (r *Repository) UpdateTenant(id string, update UpdateTenant) error {
 		"is_active": update.IsActive,
 	}
 
-	result, err := r.db.NamedExec(query, params)
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sqlx.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	result, err := tx.NamedExec(query, params)
 	if err != nil {
 		return fmt.Errorf("failed to update tenant: %w", err)
 	}
@@ -101,9 +111,20 @@ func (r *Repository) UpdateTenant(id string, update UpdateTenant) error {
 		return sql.ErrNoRows
 	}
 
+	if update.IsActive != nil && !*update.IsActive {
+		if err := revokeTenantTokens(tx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tenant update: %w", err)
+	}
+
 	return nil
 }
 
+// DeleteTenant deactivates the tenant and revokes every token issued to it.
 func (r *Repository) DeleteTenant(id string) error {
 	query := `
 		UPDATE tenants 
@@ -111,7 +132,15 @@ func (r *Repository) DeleteTenant(id string) error {
 		WHERE id = ?
 	`
 
-	result, err := r.db.Exec(query, id)
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sqlx.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete enant: %w", err)
 	}
@@ -124,6 +153,31 @@ func (r *Repository) DeleteTenant(id string) error {
 		return sql.ErrNoRows
 	}
 
+	if err := revokeTenantTokens(tx, id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tenant deletion: %w", err)
+	}
+
+	return nil
+}
+
+// revokeTenantTokens revokes the outstanding agent and admin tokens of a
+// tenant that is being deactivated. Reactivating the tenant does not bring
+// them back.
+func revokeTenantTokens(tx *sqlx.Tx, tenantID string) error {
+	for _, table := range []string{"jwt_tokens", "admin_tokens"} {
+		query := `UPDATE ` + table + `
+			SET revoked = 1, revoked_at = CURRENT_TIMESTAMP
+			WHERE tenant_id = ? AND revoked = 0`
+
+		if _, err := tx.Exec(query, tenantID); err != nil {
+			return fmt.Errorf("failed to revoke tenant tokens in %s: %w", table, err)
+		}
+	}
+
 	return nil
 }
 
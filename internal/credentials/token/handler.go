@@ -1,32 +1,30 @@
 package token
 
 import (
-	"encoding/json"
 	"net/http"
 	"time"
 
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/signing"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 )
 
 type JWTHandler struct {
 	tokenRepo *Repository
 	connRepo  *connection_data.Repository
-	jwtSecret string
+	signer    *signing.KeyManager
 }
 
 func NewJWTHandler(
 	tokenRepo *Repository,
 	connRepo *connection_data.Repository,
-	jwtSecret string,
+	signer *signing.KeyManager,
 ) *JWTHandler {
 	return &JWTHandler{
 		tokenRepo: tokenRepo,
 		connRepo:  connRepo,
-		jwtSecret: jwtSecret,
+		signer:    signer,
 	}
 }
 
@@ -53,52 +51,13 @@ func (h *JWTHandler) IssueToken(c *gin.Context) {
 		}
 	}
 
-	now := time.Now()
 	ttl := time.Duration(req.TTLSeconds) * time.Second
-	expiresAt := now.Add(ttl)
-	jti := uuid.New().String()
-
-	pinoqlClaims := claims.PinoQLClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   tenantID,
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			IssuedAt:  jwt.NewNumericDate(now),
-			NotBefore: jwt.NewNumericDate(now),
-			ID:        jti,
-			Issuer:    "pinoql-mcp",
-		},
-		TenantID:      tenantID,
-		ConnectionIDs: req.ConnectionIDs,
-		Permissions:   req.Permissions,
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, pinoqlClaims)
-	signedToken, err := token.SignedString([]byte(h.jwtSecret))
+	response, err := Issue(h.signer, h.tokenRepo, tenantID, req.ConnectionIDs, req.Permissions, ttl)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign token"})
-		return
-	}
-
-	connectionIDsJSON, _ := json.Marshal(req.ConnectionIDs)
-	newToken := NewJWTToken{
-		TenantID:      tenantID,
-		ConnectionIDs: string(connectionIDsJSON),
-		IssuedAt:      now,
-		ExpiresAt:     expiresAt,
-	}
-
-	err = h.tokenRepo.InsertToken(jti, newToken)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	response := claims.JWTIssueResponse{
-		Token:     signedToken,
-		ExpiresAt: expiresAt.Unix(),
-		JTI:       jti,
-	}
-
 	c.JSON(http.StatusCreated, response)
 }
 
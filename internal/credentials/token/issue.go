@@ -0,0 +1,66 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/signing"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Issue signs an agent token and records it so that it shows up in listings
+// and can be revoked. Connection access must already have been checked.
+func Issue(
+	signer *signing.KeyManager,
+	repo *Repository,
+	tenantID string,
+	connectionIDs []string,
+	permissions claims.ConnectionPermissions,
+	ttl time.Duration,
+	audience ...string,
+) (*claims.JWTIssueResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	jti := uuid.New().String()
+
+	pinoqlClaims := claims.PinoQLClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   tenantID,
+			Audience:  audience,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ID:        jti,
+			Issuer:    "pinoql-mcp",
+		},
+		TenantID:      tenantID,
+		ConnectionIDs: connectionIDs,
+		Permissions:   permissions,
+	}
+
+	signedToken, err := signer.Sign(pinoqlClaims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	connectionIDsJSON, _ := json.Marshal(connectionIDs)
+	newToken := NewJWTToken{
+		TenantID:      tenantID,
+		ConnectionIDs: string(connectionIDsJSON),
+		IssuedAt:      now,
+		ExpiresAt:     expiresAt,
+	}
+
+	if err := repo.InsertToken(jti, newToken); err != nil {
+		return nil, fmt.Errorf("failed to store token: %w", err)
+	}
+
+	return &claims.JWTIssueResponse{
+		Token:     signedToken,
+		ExpiresAt: expiresAt.Unix(),
+		JTI:       jti,
+	}, nil
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 )
 
 const (
@@ -15,17 +16,40 @@ const (
 type Envelope struct {
 	CiphertextHex string
 	WrappedDEKHex string
+	// KeyID identifies the key that wrapped the DEK. It is empty for
+	// envelopes written before key IDs were recorded.
+	KeyID string
 }
 
+// CryptoManager encrypts data with a fresh DEK per envelope and delegates
+// wrapping of the DEK to a KeyProvider, so the key-encryption key never has
+// to be held by this process. Previous providers are only used to unwrap
+// DEKs that have not been re-wrapped yet, which is what allows moving between
+// keys or providers.
 type CryptoManager struct {
-	MasterKey []byte
+	provider  KeyProvider
+	providers []KeyProvider
 }
 
-func NewCryptoManager(masterKey []byte) (*CryptoManager, error) {
-	if len(masterKey) != 32 {
-		return nil, errors.New("master key must be 32 bytes")
+// NewCryptoManager creates a manager backed by a local keyring that encrypts
+// with masterKey and can also decrypt DEKs wrapped with any of previousKeys.
+func NewCryptoManager(masterKey []byte, previousKeys ...[]byte) (*CryptoManager, error) {
+	keyring, err := NewKeyring(masterKey, previousKeys...)
+	if err != nil {
+		return nil, err
+	}
+	return NewCryptoManagerWithProvider(keyring), nil
+}
+
+func NewCryptoManagerWithProvider(provider KeyProvider, previous ...KeyProvider) *CryptoManager {
+	return &CryptoManager{
+		provider:  provider,
+		providers: append([]KeyProvider{provider}, previous...),
 	}
-	return &CryptoManager{MasterKey: masterKey}, nil
+}
+
+func (cm *CryptoManager) ActiveKeyID() string {
+	return cm.provider.ActiveKeyID()
 }
 
 func randomBytes(size int) ([]byte, error) {
@@ -42,7 +66,10 @@ func newGCM(key []byte) (cipher.AEAD, error) {
 	return cipher.NewGCM(block)
 }
 
-func (cm *CryptoManager) Encrypt(plaintext []byte) (*Envelope, error) {
+// Encrypt seals plaintext under a fresh DEK. associatedData is authenticated
+// but not stored: Decrypt must be given the same value, which binds the
+// ciphertext to the context it was written for.
+func (cm *CryptoManager) Encrypt(plaintext, associatedData []byte) (*Envelope, error) {
 	dek, err := randomBytes(dekSize)
 	if err != nil {
 		return nil, err
@@ -62,76 +89,123 @@ func (cm *CryptoManager) Encrypt(plaintext []byte) (*Envelope, error) {
 		dataNonce,
 		dataNonce,
 		plaintext,
-		nil,
+		associatedData,
 	)
 
-	kekGCM, err := newGCM(cm.MasterKey)
-	if err != nil {
-		return nil, err
-	}
-
-	kekNonce, err := randomBytes(kekGCM.NonceSize())
+	wrappedDEK, keyID, err := cm.provider.WrapDEK(dek)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to wrap DEK: %w", err)
 	}
 
-	wrappedDEK := kekGCM.Seal(
-		kekNonce,
-		kekNonce,
-		dek,
-		nil,
-	)
-
 	return &Envelope{
 		CiphertextHex: hex.EncodeToString(ciphertext),
 		WrappedDEKHex: hex.EncodeToString(wrappedDEK),
+		KeyID:         keyID,
 	}, nil
 }
 
-func (cm *CryptoManager) Decrypt(env *Envelope) ([]byte, error) {
+func (cm *CryptoManager) Decrypt(env *Envelope, associatedData []byte) ([]byte, error) {
 	ciphertext, err := hex.DecodeString(env.CiphertextHex)
 	if err != nil {
 		return nil, err
 	}
 
-	wrappedDEK, err := hex.DecodeString(env.WrappedDEKHex)
+	dek, _, err := cm.unwrapDEK(env)
 	if err != nil {
 		return nil, err
 	}
 
-	kekGCM, err := newGCM(cm.MasterKey)
+	dataGCM, err := newGCM(dek)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(wrappedDEK) < kekGCM.NonceSize() {
-		return nil, errors.New("invalid wrapped DEK")
+	if len(ciphertext) < dataGCM.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
 	}
 
-	kekNonce := wrappedDEK[:kekGCM.NonceSize()]
-	encDEK := wrappedDEK[kekGCM.NonceSize():]
+	dataNonce := ciphertext[:dataGCM.NonceSize()]
+	encData := ciphertext[dataGCM.NonceSize():]
 
-	dek, err := kekGCM.Open(nil, kekNonce, encDEK, nil)
+	plaintext, err := dataGCM.Open(nil, dataNonce, encData, associatedData)
 	if err != nil {
 		return nil, err
 	}
 
-	dataGCM, err := newGCM(dek)
+	return plaintext, nil
+}
+
+// RewrapDEK returns env with its DEK wrapped under the active key. The DSN
+// ciphertext is left untouched, so rotating the key-encryption key never
+// requires re-encrypting the data itself. DEKs already held by an active
+// provider implementing Rewrapper are rewrapped by it, without unwrapping.
+func (cm *CryptoManager) RewrapDEK(env *Envelope) (*Envelope, error) {
+	if rewrapper, ok := cm.provider.(Rewrapper); ok && env.KeyID != "" && cm.provider.HasKey(env.KeyID) {
+		if env.KeyID == cm.ActiveKeyID() {
+			return env, nil
+		}
+
+		wrappedDEK, err := hex.DecodeString(env.WrappedDEKHex)
+		if err != nil {
+			return nil, err
+		}
+
+		rewrappedDEK, newKeyID, err := rewrapper.RewrapDEK(wrappedDEK, env.KeyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrap DEK: %w", err)
+		}
+
+		return &Envelope{
+			CiphertextHex: env.CiphertextHex,
+			WrappedDEKHex: hex.EncodeToString(rewrappedDEK),
+			KeyID:         newKeyID,
+		}, nil
+	}
+
+	dek, keyID, err := cm.unwrapDEK(env)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(ciphertext) < dataGCM.NonceSize() {
-		return nil, errors.New("invalid ciphertext")
+	if keyID == cm.ActiveKeyID() && env.KeyID == keyID {
+		return env, nil
 	}
 
-	dataNonce := ciphertext[:dataGCM.NonceSize()]
-	encData := ciphertext[dataGCM.NonceSize():]
+	wrappedDEK, newKeyID, err := cm.provider.WrapDEK(dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap DEK: %w", err)
+	}
+
+	return &Envelope{
+		CiphertextHex: env.CiphertextHex,
+		WrappedDEKHex: hex.EncodeToString(wrappedDEK),
+		KeyID:         newKeyID,
+	}, nil
+}
 
-	plaintext, err := dataGCM.Open(nil, dataNonce, encData, nil)
+// unwrapDEK returns the DEK in env and the ID of the key that wrapped it.
+// Envelopes without a key ID are offered to every provider in turn.
+func (cm *CryptoManager) unwrapDEK(env *Envelope) ([]byte, string, error) {
+	wrappedDEK, err := hex.DecodeString(env.WrappedDEKHex)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return plaintext, nil
+	if env.KeyID != "" {
+		for _, provider := range cm.providers {
+			if provider.HasKey(env.KeyID) {
+				dek, err := provider.UnwrapDEK(wrappedDEK, env.KeyID)
+				return dek, env.KeyID, err
+			}
+		}
+		return nil, "", fmt.Errorf("unknown master key id %q", env.KeyID)
+	}
+
+	for _, provider := range cm.providers {
+		if dek, keyID, err := provider.UnwrapLegacyDEK(wrappedDEK); err == nil {
+			return dek, keyID, nil
+		}
+	}
+
+	return nil, "", errors.New("no master key can unwrap the DEK")
 }
@@ -0,0 +1,172 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Keyring is a KeyProvider holding AES-256 master keys in memory: the active
+// one wraps new DEKs and all of them can unwrap.
+type Keyring struct {
+	activeKeyID string
+	masterKeys  map[string][]byte
+	// keyOrder keeps the active key first, so legacy envelopes without a key
+	// ID try the most likely key first.
+	keyOrder []string
+}
+
+var _ KeyProvider = (*Keyring)(nil)
+
+func NewKeyring(masterKey []byte, previousKeys ...[]byte) (*Keyring, error) {
+	k := &Keyring{masterKeys: map[string][]byte{}}
+
+	for i, key := range append([][]byte{masterKey}, previousKeys...) {
+		if len(key) != 32 {
+			return nil, errors.New("master key must be 32 bytes")
+		}
+		id := KeyID(key)
+		if _, ok := k.masterKeys[id]; ok {
+			continue
+		}
+		if i == 0 {
+			k.activeKeyID = id
+		}
+		k.masterKeys[id] = key
+		k.keyOrder = append(k.keyOrder, id)
+	}
+
+	return k, nil
+}
+
+// keyringFile is the on-disk format read by LoadKeyringFile. Keys are base64
+// encoded; previous keys are only used for decryption.
+type keyringFile struct {
+	ActiveKey    string   `json:"active_key"`
+	PreviousKeys []string `json:"previous_keys"`
+}
+
+// LoadKeyFile reads a single base64-encoded master key from path.
+func LoadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key in %s: %w", path, err)
+	}
+
+	return key, nil
+}
+
+// LoadKeyringFile reads a JSON keyring of the form
+// {"active_key": "<base64>", "previous_keys": ["<base64>", ...]}.
+func LoadKeyringFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid keyring %s: %w", path, err)
+	}
+
+	activeKey, err := base64.StdEncoding.DecodeString(file.ActiveKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid active key in %s: %w", path, err)
+	}
+
+	previousKeys := make([][]byte, 0, len(file.PreviousKeys))
+	for _, keyB64 := range file.PreviousKeys {
+		key, err := base64.StdEncoding.DecodeString(keyB64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous key in %s: %w", path, err)
+		}
+		previousKeys = append(previousKeys, key)
+	}
+
+	return NewKeyring(activeKey, previousKeys...)
+}
+
+// KeyID returns a stable identifier for a master key: a truncated SHA-256
+// fingerprint, which reveals nothing useful about the key itself.
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeKeyID
+}
+
+func (k *Keyring) HasKey(keyID string) bool {
+	_, ok := k.masterKeys[keyID]
+	return ok
+}
+
+func (k *Keyring) WrapDEK(dek []byte) ([]byte, string, error) {
+	wrapped, err := sealDEK(k.masterKeys[k.activeKeyID], dek)
+	return wrapped, k.activeKeyID, err
+}
+
+func (k *Keyring) UnwrapDEK(wrapped []byte, keyID string) ([]byte, error) {
+	key, ok := k.masterKeys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key id %q", keyID)
+	}
+	return openDEK(key, wrapped)
+}
+
+// UnwrapLegacyDEK tries every key in turn; GCM authentication guarantees a
+// wrong key is never mistaken for the right one.
+func (k *Keyring) UnwrapLegacyDEK(wrapped []byte) ([]byte, string, error) {
+	for _, id := range k.keyOrder {
+		if dek, err := openDEK(k.masterKeys[id], wrapped); err == nil {
+			return dek, id, nil
+		}
+	}
+	return nil, "", errors.New("no master key can unwrap the DEK")
+}
+
+func sealDEK(masterKey, dek []byte) ([]byte, error) {
+	kekGCM, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kekNonce, err := randomBytes(kekGCM.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	return kekGCM.Seal(
+		kekNonce,
+		kekNonce,
+		dek,
+		nil,
+	), nil
+}
+
+func openDEK(masterKey, wrappedDEK []byte) ([]byte, error) {
+	kekGCM, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrappedDEK) < kekGCM.NonceSize() {
+		return nil, errors.New("invalid wrapped DEK")
+	}
+
+	kekNonce := wrappedDEK[:kekGCM.NonceSize()]
+	encDEK := wrappedDEK[kekGCM.NonceSize():]
+
+	return kekGCM.Open(nil, kekNonce, encDEK, nil)
+}
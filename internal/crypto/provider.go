@@ -0,0 +1,25 @@
+package crypto
+
+// KeyProvider wraps and unwraps DEKs with a key-encryption key it controls.
+// Implementations may hold the key locally or delegate to an external KMS.
+type KeyProvider interface {
+	// ActiveKeyID identifies the key WrapDEK currently uses.
+	ActiveKeyID() string
+	// HasKey reports whether keyID was issued by this provider.
+	HasKey(keyID string) bool
+	// WrapDEK wraps dek with the active key and returns the ID of that key.
+	WrapDEK(dek []byte) ([]byte, string, error)
+	// UnwrapDEK unwraps a DEK previously wrapped under keyID.
+	UnwrapDEK(wrapped []byte, keyID string) ([]byte, error)
+	// UnwrapLegacyDEK unwraps a DEK stored without a key ID, returning the ID
+	// of the key that turned out to wrap it.
+	UnwrapLegacyDEK(wrapped []byte) ([]byte, string, error)
+}
+
+// Rewrapper is implemented by providers that can move a DEK to their active
+// key without exposing it to this process.
+type Rewrapper interface {
+	// RewrapDEK rewraps a DEK wrapped under keyID with the active key and
+	// returns the ID of that key.
+	RewrapDEK(wrapped []byte, keyID string) ([]byte, string, error)
+}
@@ -0,0 +1,249 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	vaultRequestTimeout = 10 * time.Second
+	// vaultKeyCacheTTL bounds how long the latest Transit key version is
+	// reused before Vault is asked again.
+	vaultKeyCacheTTL = time.Minute
+	// vaultKeyRetryInterval spaces out lookups while Vault cannot tell the
+	// latest version, so an outage does not stall every call.
+	vaultKeyRetryInterval = 10 * time.Second
+)
+
+// VaultTransitProvider wraps DEKs with HashiCorp Vault's Transit secrets
+// engine (or any server speaking the same API), so the key-encryption key
+// never leaves Vault. Key IDs carry the Transit key version, so after the key
+// is rotated in Vault a rotate-keys run moves every DEK to the latest version
+// through Transit's rewrap endpoint.
+type VaultTransitProvider struct {
+	addr      string
+	token     string
+	namespace string
+	mount     string
+	keyName   string
+	client    *http.Client
+
+	mu            sync.Mutex
+	latestVersion int
+	// nextLookup is when the latest version is looked up again.
+	nextLookup time.Time
+}
+
+var _ KeyProvider = (*VaultTransitProvider)(nil)
+
+type VaultTransitConfig struct {
+	Address   string
+	Token     string
+	Namespace string
+	Mount     string
+	KeyName   string
+}
+
+func NewVaultTransitProvider(cfg VaultTransitConfig) (*VaultTransitProvider, error) {
+	if cfg.Address == "" || cfg.Token == "" || cfg.KeyName == "" {
+		return nil, errors.New("vault address, token and key name are required")
+	}
+
+	mount := cfg.Mount
+	if mount == "" {
+		mount = "transit"
+	}
+
+	return &VaultTransitProvider{
+		addr:      strings.TrimRight(cfg.Address, "/"),
+		token:     cfg.Token,
+		namespace: cfg.Namespace,
+		mount:     strings.Trim(mount, "/"),
+		keyName:   cfg.KeyName,
+		client:    &http.Client{Timeout: vaultRequestTimeout},
+	}, nil
+}
+
+// ActiveKeyID identifies the latest version of the Transit key. When Vault
+// cannot be reached the last known version is used, or the unversioned ID
+// written before key versions were recorded, and the lookup is retried after
+// vaultKeyRetryInterval.
+func (v *VaultTransitProvider) ActiveKeyID() string {
+	v.mu.Lock()
+	version, lookup := v.latestVersion, !time.Now().Before(v.nextLookup)
+	if lookup {
+		// Claim the lookup; until it succeeds, other callers and retries
+		// use the last known version.
+		v.nextLookup = time.Now().Add(vaultKeyRetryInterval)
+	}
+	v.mu.Unlock()
+
+	if lookup {
+		var resp struct {
+			Data struct {
+				LatestVersion int `json:"latest_version"`
+			} `json:"data"`
+		}
+		if err := v.call(http.MethodGet, "keys", nil, &resp); err == nil {
+			version = resp.Data.LatestVersion
+
+			v.mu.Lock()
+			v.latestVersion = version
+			v.nextLookup = time.Now().Add(vaultKeyCacheTTL)
+			v.mu.Unlock()
+		}
+	}
+
+	return v.keyID(version)
+}
+
+func (v *VaultTransitProvider) HasKey(keyID string) bool {
+	base := v.keyID(0)
+	return keyID == base || strings.HasPrefix(keyID, base+":v")
+}
+
+// keyID returns the ID of a version of the Transit key; version 0 yields the
+// unversioned ID.
+func (v *VaultTransitProvider) keyID(version int) string {
+	id := "vault:" + v.mount + "/" + v.keyName
+	if version > 0 {
+		id += ":v" + strconv.Itoa(version)
+	}
+	return id
+}
+
+// ciphertextKeyID returns the ID of the key version recorded in a Transit
+// ciphertext, which has the form "vault:v<version>:<data>".
+func (v *VaultTransitProvider) ciphertextKeyID(ciphertext []byte) string {
+	version, _, _ := strings.Cut(strings.TrimPrefix(string(ciphertext), "vault:v"), ":")
+	n, err := strconv.Atoi(version)
+	if err != nil {
+		return v.keyID(0)
+	}
+	return v.keyID(n)
+}
+
+func (v *VaultTransitProvider) WrapDEK(dek []byte) ([]byte, string, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+
+	err := v.call(http.MethodPost, "encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(dek),
+	}, &resp)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []byte(resp.Data.Ciphertext), v.ciphertextKeyID([]byte(resp.Data.Ciphertext)), nil
+}
+
+// RewrapDEK moves a DEK to the latest Transit key version without it ever
+// leaving Vault.
+func (v *VaultTransitProvider) RewrapDEK(wrapped []byte, keyID string) ([]byte, string, error) {
+	if !v.HasKey(keyID) {
+		return nil, "", fmt.Errorf("unknown master key id %q", keyID)
+	}
+
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+
+	err := v.call(http.MethodPost, "rewrap", map[string]string{
+		"ciphertext": string(wrapped),
+	}, &resp)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []byte(resp.Data.Ciphertext), v.ciphertextKeyID([]byte(resp.Data.Ciphertext)), nil
+}
+
+func (v *VaultTransitProvider) UnwrapDEK(wrapped []byte, keyID string) ([]byte, error) {
+	if !v.HasKey(keyID) {
+		return nil, fmt.Errorf("unknown master key id %q", keyID)
+	}
+
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+
+	err := v.call(http.MethodPost, "decrypt", map[string]string{
+		"ciphertext": string(wrapped),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+// UnwrapLegacyDEK only accepts Transit ciphertexts; DEKs wrapped by a local
+// key before moving to Vault must be unwrapped by a previous provider.
+func (v *VaultTransitProvider) UnwrapLegacyDEK(wrapped []byte) ([]byte, string, error) {
+	if !bytes.HasPrefix(wrapped, []byte("vault:")) {
+		return nil, "", errors.New("not a vault transit ciphertext")
+	}
+	keyID := v.ciphertextKeyID(wrapped)
+	dek, err := v.UnwrapDEK(wrapped, keyID)
+	return dek, keyID, err
+}
+
+func (v *VaultTransitProvider) call(method, operation string, body any, out any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", v.addr, v.mount, operation, v.keyName)
+	req, err := http.NewRequest(method, url, payload)
+	if err != nil {
+		return fmt.Errorf("failed to build vault request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault %s request failed: %w", operation, err)
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&vaultErr)
+		return fmt.Errorf("vault %s failed with status %d: %s", operation, resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode vault %s response: %w", operation, err)
+	}
+
+	return nil
+}
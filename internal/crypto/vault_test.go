@@ -0,0 +1,290 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTransit emulates the parts of Vault's Transit engine the provider uses.
+// Ciphertexts are "vault:v<version>:" followed by the version key XORed with
+// the plaintext, which is enough to tell versions apart.
+type fakeTransit struct {
+	mu       sync.Mutex
+	version  int
+	requests map[string]int
+	// unavailable makes every counted request fail with 503.
+	unavailable bool
+}
+
+func newFakeTransit(t *testing.T) (*fakeTransit, *VaultTransitProvider) {
+	t.Helper()
+
+	f := &fakeTransit{version: 1, requests: map[string]int{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	provider, err := NewVaultTransitProvider(VaultTransitConfig{
+		Address: server.URL,
+		Token:   "root",
+		KeyName: "dsn",
+	})
+	if err != nil {
+		t.Fatalf("NewVaultTransitProvider: %v", err)
+	}
+
+	return f, provider
+}
+
+func (f *fakeTransit) rotate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.version++
+}
+
+func (f *fakeTransit) count(operation string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[operation]
+}
+
+func (f *fakeTransit) seal(version int, plaintext []byte) string {
+	sealed := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		sealed[i] = b ^ byte(version)
+	}
+	return fmt.Sprintf("vault:v%d:%s", version, base64.StdEncoding.EncodeToString(sealed))
+}
+
+func (f *fakeTransit) open(ciphertext string) ([]byte, error) {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("invalid ciphertext")
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	if err != nil || version < 1 || version > f.version {
+		return nil, fmt.Errorf("invalid key version")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	for i := range sealed {
+		sealed[i] ^= byte(version)
+	}
+	return sealed, nil
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{message}})
+	}
+
+	if r.Header.Get("X-Vault-Token") != "root" {
+		fail(http.StatusForbidden, "permission denied")
+		return
+	}
+
+	operation, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/transit/"), "/")
+	if key != "dsn" {
+		fail(http.StatusNotFound, "unknown key")
+		return
+	}
+	f.requests[operation]++
+	if f.unavailable {
+		fail(http.StatusServiceUnavailable, "Vault is sealed")
+		return
+	}
+
+	var body map[string]string
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var data map[string]any
+	switch {
+	case operation == "keys" && r.Method == http.MethodGet:
+		data = map[string]any{"latest_version": f.version}
+
+	case operation == "encrypt":
+		plaintext, err := base64.StdEncoding.DecodeString(body["plaintext"])
+		if err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		data = map[string]any{"ciphertext": f.seal(f.version, plaintext)}
+
+	case operation == "decrypt":
+		plaintext, err := f.open(body["ciphertext"])
+		if err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		data = map[string]any{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}
+
+	case operation == "rewrap":
+		plaintext, err := f.open(body["ciphertext"])
+		if err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		data = map[string]any{"ciphertext": f.seal(f.version, plaintext)}
+
+	default:
+		fail(http.StatusMethodNotAllowed, "unsupported operation")
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func TestVaultTransitRewrapAfterRotation(t *testing.T) {
+	transit, provider := newFakeTransit(t)
+	cm := NewCryptoManagerWithProvider(provider)
+
+	associatedData := []byte("tenant/connection")
+	env, err := cm.Encrypt([]byte("postgres://secret"), associatedData)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if env.KeyID != "vault:transit/dsn:v1" {
+		t.Fatalf("KeyID = %q, want vault:transit/dsn:v1", env.KeyID)
+	}
+
+	same, err := cm.RewrapDEK(env)
+	if err != nil {
+		t.Fatalf("RewrapDEK before rotation: %v", err)
+	}
+	if same != env {
+		t.Fatalf("RewrapDEK rewrapped a DEK already under the active key")
+	}
+
+	transit.rotate()
+	// Skip the version cache instead of waiting for it to expire.
+	provider.nextLookup = time.Time{}
+
+	if got := cm.ActiveKeyID(); got != "vault:transit/dsn:v2" {
+		t.Fatalf("ActiveKeyID after rotation = %q, want vault:transit/dsn:v2", got)
+	}
+
+	decrypts := transit.count("decrypt")
+	rewrapped, err := cm.RewrapDEK(env)
+	if err != nil {
+		t.Fatalf("RewrapDEK after rotation: %v", err)
+	}
+	if rewrapped.KeyID != "vault:transit/dsn:v2" {
+		t.Fatalf("rewrapped KeyID = %q, want vault:transit/dsn:v2", rewrapped.KeyID)
+	}
+	if rewrapped.CiphertextHex != env.CiphertextHex {
+		t.Fatalf("RewrapDEK changed the data ciphertext")
+	}
+	if transit.count("rewrap") != 1 || transit.count("decrypt") != decrypts {
+		t.Fatalf("RewrapDEK should use rewrap without decrypting (rewrap=%d, decrypt=%d)",
+			transit.count("rewrap"), transit.count("decrypt")-decrypts)
+	}
+
+	plaintext, err := cm.Decrypt(rewrapped, associatedData)
+	if err != nil {
+		t.Fatalf("Decrypt rewrapped envelope: %v", err)
+	}
+	if !bytes.Equal(plaintext, []byte("postgres://secret")) {
+		t.Fatalf("Decrypt = %q", plaintext)
+	}
+}
+
+func TestVaultTransitUnversionedKeyID(t *testing.T) {
+	_, provider := newFakeTransit(t)
+	cm := NewCryptoManagerWithProvider(provider)
+
+	env, err := cm.Encrypt([]byte("dsn"), nil)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	// Envelopes written before key versions were recorded.
+	legacy := *env
+	legacy.KeyID = "vault:transit/dsn"
+	if !provider.HasKey(legacy.KeyID) {
+		t.Fatalf("HasKey(%q) = false", legacy.KeyID)
+	}
+
+	rewrapped, err := cm.RewrapDEK(&legacy)
+	if err != nil {
+		t.Fatalf("RewrapDEK: %v", err)
+	}
+	if rewrapped.KeyID != "vault:transit/dsn:v1" {
+		t.Fatalf("rewrapped KeyID = %q, want vault:transit/dsn:v1", rewrapped.KeyID)
+	}
+
+	// Envelopes without any key ID are matched through the ciphertext.
+	legacy.KeyID = ""
+	plaintext, err := cm.Decrypt(&legacy, nil)
+	if err != nil || string(plaintext) != "dsn" {
+		t.Fatalf("Decrypt without key ID = %q, %v", plaintext, err)
+	}
+}
+
+func TestVaultTransitErrors(t *testing.T) {
+	_, provider := newFakeTransit(t)
+	provider.token = "wrong"
+
+	if _, _, err := provider.WrapDEK([]byte("dek")); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("WrapDEK with a bad token: %v", err)
+	}
+	if got := provider.ActiveKeyID(); got != "vault:transit/dsn" {
+		t.Fatalf("ActiveKeyID without Vault = %q, want the unversioned ID", got)
+	}
+	if _, err := provider.UnwrapDEK([]byte("vault:v1:AA=="), "local:abc"); err == nil {
+		t.Fatalf("UnwrapDEK accepted a foreign key ID")
+	}
+}
+
+func TestVaultTransitKeyLookupBacksOff(t *testing.T) {
+	transit, provider := newFakeTransit(t)
+
+	transit.mu.Lock()
+	transit.unavailable = true
+	transit.mu.Unlock()
+
+	// A failed lookup is not retried before vaultKeyRetryInterval.
+	for range 3 {
+		if got := provider.ActiveKeyID(); got != "vault:transit/dsn" {
+			t.Fatalf("ActiveKeyID without Vault = %q, want the unversioned ID", got)
+		}
+	}
+	if keys := transit.count("keys"); keys != 1 {
+		t.Fatalf("key version looked up %d times, want 1", keys)
+	}
+
+	transit.mu.Lock()
+	transit.unavailable = false
+	transit.mu.Unlock()
+
+	provider.mu.Lock()
+	provider.nextLookup = time.Time{}
+	provider.mu.Unlock()
+
+	for range 3 {
+		if got := provider.ActiveKeyID(); got != "vault:transit/dsn:v1" {
+			t.Fatalf("ActiveKeyID after Vault recovered = %q, want vault:transit/dsn:v1", got)
+		}
+	}
+	if keys := transit.count("keys"); keys != 2 {
+		t.Fatalf("key version looked up %d times, want 2", keys)
+	}
+}
@@ -0,0 +1,22 @@
+package errors
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitOpenError is returned instead of dialing a connection whose recent
+// health checks kept failing.
+type CircuitOpenError struct {
+	ConnectionID string
+	Failures     int
+	LastError    string
+	RetryAt      time.Time
+}
+
+func (e CircuitOpenError) Error() string {
+	return fmt.Sprintf(
+		"Connection %v is unavailable: %d consecutive health checks failed (last error: %v). Retry after %v",
+		e.ConnectionID, e.Failures, e.LastError, e.RetryAt.UTC().Format(time.RFC3339),
+	)
+}
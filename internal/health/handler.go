@@ -0,0 +1,43 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	monitor  *Monitor
+	connRepo *connection_data.Repository
+}
+
+func NewHealthHandler(monitor *Monitor, connRepo *connection_data.Repository) *Handler {
+	return &Handler{monitor: monitor, connRepo: connRepo}
+}
+
+// GetConnectionHealth returns the latest health status of one of the
+// tenant's connections, checking it on the spot if the monitor has not
+// reached it yet.
+func (h *Handler) GetConnectionHealth(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	if tenantID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "tenant_id not found in context"})
+		return
+	}
+
+	connectionID := c.Param("id")
+
+	conn, err := h.connRepo.GetConnectionWithDSN(tenantID, connectionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, ok := h.monitor.Status(conn.ID)
+	if !ok {
+		status = h.monitor.Check(c.Request.Context(), conn)
+	}
+
+	c.JSON(http.StatusOK, status)
+}
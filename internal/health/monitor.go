@@ -0,0 +1,170 @@
+package health
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+)
+
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultTimeout  = 5 * time.Second
+
+	// maxConcurrentChecks bounds how many databases are pinged at once.
+	maxConcurrentChecks = 8
+)
+
+type Config struct {
+	// Interval between checks of every active connection. Zero or negative
+	// disables the background monitor; on-demand checks still work.
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// Status is the outcome of the latest health check of a connection.
+type Status struct {
+	ConnectionID        string                  `json:"connection_id"`
+	Healthy             bool                    `json:"healthy"`
+	LatencyMs           int64                   `json:"latency_ms"`
+	Error               string                  `json:"error,omitempty"`
+	CheckedAt           time.Time               `json:"checked_at"`
+	ConsecutiveFailures int                     `json:"consecutive_failures"`
+	CircuitState        connection.BreakerState `json:"circuit_state"`
+}
+
+// Monitor pings every active connection on a schedule through the
+// connection manager, which trips the connection's circuit breaker after
+// repeated failures, and keeps the latest status of each one.
+type Monitor struct {
+	connRepo *connection_data.Repository
+	manager  *connection.Manager
+	cfg      Config
+
+	mu       sync.RWMutex
+	statuses map[string]Status
+}
+
+func NewMonitor(connRepo *connection_data.Repository, manager *connection.Manager, cfg Config) *Monitor {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	return &Monitor{
+		connRepo: connRepo,
+		manager:  manager,
+		cfg:      cfg,
+		statuses: map[string]Status{},
+	}
+}
+
+// Start checks every active connection right away and then once per
+// interval, until ctx is done.
+func (m *Monitor) Start(ctx context.Context) {
+	if m.cfg.Interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			m.checkAll(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Status returns the latest status of a connection, if it was checked.
+func (m *Monitor) Status(connectionID string) (Status, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status, ok := m.statuses[connectionID]
+	return status, ok
+}
+
+// Check pings a connection now and records the result. conn must have been
+// loaded with its decrypted DSN.
+func (m *Monitor) Check(ctx context.Context, conn *connection_data.ConnectionData) Status {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := m.manager.Check(ctx, connection.FromConnectionData(conn))
+	latency := time.Since(start)
+
+	state, failures := m.manager.BreakerStatus(conn.ID)
+	status := Status{
+		ConnectionID:        conn.ID,
+		Healthy:             err == nil,
+		LatencyMs:           latency.Milliseconds(),
+		CheckedAt:           time.Now().UTC(),
+		ConsecutiveFailures: failures,
+		CircuitState:        state,
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	m.mu.Lock()
+	m.statuses[conn.ID] = status
+	m.mu.Unlock()
+
+	return status
+}
+
+func (m *Monitor) checkAll(ctx context.Context) {
+	connections, err := m.connRepo.ListActiveConnectionsWithDSN()
+	if err != nil {
+		log.Printf("Health monitor: %v", err)
+	}
+	if connections == nil {
+		return
+	}
+
+	active := make(map[string]bool, len(connections))
+	sem := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+
+	for _, conn := range connections {
+		active[conn.ID] = true
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(conn *connection_data.ConnectionData) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			status := m.Check(ctx, conn)
+			if !status.Healthy {
+				log.Printf("Health check of connection %s failed: %s", conn.ID, status.Error)
+			}
+		}(conn)
+	}
+	wg.Wait()
+
+	// Forget connections that were deactivated or deleted since.
+	m.mu.Lock()
+	for id := range m.statuses {
+		if !active[id] {
+			delete(m.statuses, id)
+		}
+	}
+	m.mu.Unlock()
+}
@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/audit"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+)
+
+// record queues an audit log for a tool call, including calls rejected
+// before a connection was resolved. The SQL itself is never stored, only its
+// SHA-256 hash.
+func (h *ToolHandler) record(pinoqlClaims *claims.PinoQLClaims, connectionID, action, sql string, start time.Time, rows *int, err error) {
+	if h.auditWriter == nil {
+		return
+	}
+
+	executionTime := int(time.Since(start).Milliseconds())
+	entry := audit.NewConnectionAuditLog{
+		TenantID:        pinoqlClaims.TenantID,
+		ConnectionID:    connectionID,
+		Action:          action,
+		Success:         err == nil,
+		ExecutionTimeMs: &executionTime,
+		RowsReturned:    rows,
+	}
+
+	if sql != "" {
+		sum := sha256.Sum256([]byte(sql))
+		hash := hex.EncodeToString(sum[:])
+		entry.QueryHash = &hash
+	}
+
+	if err != nil {
+		message := err.Error()
+		entry.ErrorMessage = &message
+	}
+
+	h.auditWriter.Log(entry)
+}
@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/classifier"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+)
+
+// sessionControl are the statements that can end the read-only transaction
+// a query runs in or switch the session to read-write.
+var sessionControl = map[classifier.Operation]bool{
+	"SET": true, "RESET": true, "BEGIN": true, "START": true, "COMMIT": true,
+	"END": true, "ROLLBACK": true, "ABORT": true, "SAVEPOINT": true, "RELEASE": true,
+	"DISCARD": true,
+}
+
+// authorizeStatements rejects the batch if any statement performs an
+// operation outside AllowedOps or falls in a category the token lacks.
+// Admin statements (SET, BEGIN, CALL, DO, ...) are only accepted when listed
+// explicitly in AllowedOps or granted through "*", and since procedures and
+// anonymous blocks can do anything, only for tokens that may both write and
+// execute DDL.
+//
+// On read-only connections batches must hold a single statement and may not
+// control the session or transaction, whatever the token allows: drivers
+// send the whole string at once, so "COMMIT; DELETE ..." would otherwise end
+// the read-only transaction and run the DELETE outside of it.
+func authorizeStatements(pinoqlClaims *claims.PinoQLClaims, readOnly bool, statements []classifier.Statement) error {
+	if readOnly && len(statements) > 1 {
+		return fmt.Errorf("read-only connections accept a single statement per query")
+	}
+
+	for _, stmt := range statements {
+		for _, op := range stmt.Operations {
+			if readOnly && sessionControl[op] {
+				return fmt.Errorf("operation %s is not allowed on a read-only connection", op)
+			}
+			if !pinoqlClaims.CanExecuteOperation(string(op)) {
+				return fmt.Errorf("operation %s is not allowed for this token", op)
+			}
+		}
+
+		switch stmt.Category {
+		case classifier.CategoryRead:
+			if !pinoqlClaims.CanRead() {
+				return fmt.Errorf("token is not allowed to read data")
+			}
+		case classifier.CategoryWrite:
+			if !pinoqlClaims.CanWrite() {
+				return fmt.Errorf("token is not allowed to write data (%s)", stmt.Operation)
+			}
+		case classifier.CategoryDDL:
+			if !pinoqlClaims.CanExecuteDDL() {
+				return fmt.Errorf("token is not allowed to execute DDL (%s)", stmt.Operation)
+			}
+		case classifier.CategoryAdmin:
+			if !pinoqlClaims.CanWrite() || !pinoqlClaims.CanExecuteDDL() {
+				return fmt.Errorf("token is not allowed to run admin commands (%s)", stmt.Operation)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/classifier"
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+)
+
+func TestAuthorizeStatementsReadOnly(t *testing.T) {
+	unrestricted := &claims.PinoQLClaims{
+		Permissions: claims.ConnectionPermissions{
+			Read: true, Write: true, Schema: true, DDL: true,
+			AllowedOps: []string{"*"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		dialect  connection.Dialect
+		sql      string
+		readOnly bool
+		wantErr  bool
+	}{
+		{"single select", connection.PostgreSQL, "SELECT 1", true, false},
+		{"commit then delete", connection.PostgreSQL, "COMMIT; DELETE FROM t", true, true},
+		{"rollback then delete", connection.PostgreSQL, "ROLLBACK; DELETE FROM t", true, true},
+		{"end then update", connection.PostgreSQL, "END; UPDATE t SET x = 1", true, true},
+		{"lone commit", connection.PostgreSQL, "COMMIT", true, true},
+		{"set transaction read write", connection.PostgreSQL, "SET TRANSACTION READ WRITE", true, true},
+		{"two selects", connection.PostgreSQL, "SELECT 1; SELECT 2", true, true},
+		{"mysql session read write", connection.MySQL, "SET SESSION TRANSACTION READ WRITE; DELETE FROM t", true, true},
+		{"mysql start transaction", connection.MySQL, "START TRANSACTION READ WRITE", true, true},
+		{"batch on read-write connection", connection.PostgreSQL, "COMMIT; DELETE FROM t", false, false},
+		{"set on read-write connection", connection.MySQL, "SET SESSION TRANSACTION READ WRITE", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := classifier.Classify(tt.dialect, tt.sql)
+			if err != nil {
+				t.Fatalf("Classify(%q): %v", tt.sql, err)
+			}
+
+			err = authorizeStatements(unrestricted, tt.readOnly, statements)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("authorizeStatements(%q, readOnly=%v) error = %v, want error %v", tt.sql, tt.readOnly, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeStatementsAdmin(t *testing.T) {
+	readOnlyToken := &claims.PinoQLClaims{
+		Permissions: claims.ConnectionPermissions{Read: true, Schema: true, AllowedOps: []string{"*"}},
+	}
+	writeToken := &claims.PinoQLClaims{
+		Permissions: claims.ConnectionPermissions{Read: true, Write: true, AllowedOps: []string{"*"}},
+	}
+	adminToken := &claims.PinoQLClaims{
+		Permissions: claims.ConnectionPermissions{Read: true, Write: true, DDL: true, AllowedOps: []string{"*"}},
+	}
+
+	tests := []struct {
+		name    string
+		token   *claims.PinoQLClaims
+		dialect connection.Dialect
+		sql     string
+		wantErr bool
+	}{
+		{"do block with read-only token", readOnlyToken, connection.PostgreSQL, "DO $$ BEGIN DELETE FROM t; END $$", true},
+		{"call with read-only token", readOnlyToken, connection.PostgreSQL, "CALL proc()", true},
+		{"mysql call with read-only token", readOnlyToken, connection.MySQL, "CALL proc()", true},
+		{"copy to program with read-only token", readOnlyToken, connection.PostgreSQL, "COPY t TO PROGRAM 'id'", true},
+		{"call with write-only token", writeToken, connection.PostgreSQL, "CALL proc()", true},
+		{"select with read-only token", readOnlyToken, connection.PostgreSQL, "SELECT 1", false},
+		{"do block with admin token", adminToken, connection.PostgreSQL, "DO $$ BEGIN DELETE FROM t; END $$", false},
+		{"call with admin token", adminToken, connection.PostgreSQL, "CALL proc()", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := classifier.Classify(tt.dialect, tt.sql)
+			if err != nil {
+				t.Fatalf("Classify(%q): %v", tt.sql, err)
+			}
+
+			err = authorizeStatements(tt.token, false, statements)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("authorizeStatements(%q) error = %v, want error %v", tt.sql, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/audit"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type DescribeSchemaInput struct {
+	ConnectionID string `json:"connection_id" jsonschema:"id of the connection to describe"`
+}
+
+func (h *ToolHandler) DescribeSchema(ctx context.Context, req *mcp.CallToolRequest, input DescribeSchemaInput) (*mcp.CallToolResult, *adapters.DatabaseSchema, error) {
+	pinoqlClaims, err := claimsFromRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start := time.Now()
+
+	if !pinoqlClaims.CanAccessSchema() {
+		err := fmt.Errorf("token is not allowed to access schema information")
+		h.record(pinoqlClaims, input.ConnectionID, audit.ActionSchema, "", start, nil, err)
+		return nil, nil, err
+	}
+
+	conn, adapter, release, err := h.resolveConnection(ctx, pinoqlClaims, input.ConnectionID)
+	if err != nil {
+		h.record(pinoqlClaims, input.ConnectionID, audit.ActionConnect, "", start, nil, err)
+		return nil, nil, err
+	}
+	defer release()
+
+	timeout := queryTimeout(conn, pinoqlClaims)
+	schemaCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	schema, err := adapter.DescribeSchema(schemaCtx)
+	if err != nil {
+		err = queryError(schemaCtx, "failed to describe schema", timeout, err)
+	}
+	h.record(pinoqlClaims, input.ConnectionID, audit.ActionSchema, "", start, nil, err)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nil, schema, nil
+}
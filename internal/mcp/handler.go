@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/audit"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/middleware"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type ToolHandler struct {
+	connRepo    *connection_data.Repository
+	manager     *connection.Manager
+	auditWriter *audit.Writer
+}
+
+func NewToolHandler(connRepo *connection_data.Repository, manager *connection.Manager, auditWriter *audit.Writer) *ToolHandler {
+	return &ToolHandler{
+		connRepo:    connRepo,
+		manager:     manager,
+		auditWriter: auditWriter,
+	}
+}
+
+func (h *ToolHandler) Register(server *mcp.Server) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "run_query",
+		Description: "Runs a SQL query against one of the connections granted to the caller's token and returns the resulting rows.",
+	}, h.RunQuery)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "describe_schema",
+		Description: "Describes the schemas, tables, views, columns, keys and indexes of one of the connections granted to the caller's token.",
+	}, h.DescribeSchema)
+}
+
+func claimsFromRequest(req *mcp.CallToolRequest) (*claims.PinoQLClaims, error) {
+	extra := req.GetExtra()
+	if extra == nil {
+		return nil, fmt.Errorf("missing credentials")
+	}
+
+	pinoqlClaims, ok := middleware.ClaimsFromTokenInfo(extra.TokenInfo)
+	if !ok {
+		return nil, fmt.Errorf("missing credentials")
+	}
+
+	return pinoqlClaims, nil
+}
+
+// resolveConnection checks that the token grants access to connectionID and
+// returns the decrypted connection together with its pooled adapter. The
+// caller must call release once it is done with the adapter.
+func (h *ToolHandler) resolveConnection(ctx context.Context, pinoqlClaims *claims.PinoQLClaims, connectionID string) (*connection_data.ConnectionData, adapters.Adapter, func(), error) {
+	if connectionID == "" {
+		return nil, nil, nil, fmt.Errorf("connection_id is required")
+	}
+
+	if !pinoqlClaims.HasAccessToConnection(connectionID) {
+		return nil, nil, nil, fmt.Errorf("access denied to connection: %s", connectionID)
+	}
+
+	conn, err := h.connRepo.GetConnectionWithDSN(pinoqlClaims.TenantID, connectionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	adapter, release, err := h.manager.Acquire(ctx, connection.FromConnectionData(conn))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return conn, adapter, release, nil
+}
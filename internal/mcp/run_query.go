@@ -2,18 +2,144 @@ package mcp
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/CaioMtho/pinoql-mcp/internal/adapters"
+	"github.com/CaioMtho/pinoql-mcp/internal/classifier"
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/audit"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type QueryInput struct {
-	SQL string `json:"sql" jsonschema:"sql code to be executed"`
+	ConnectionID string `json:"connection_id" jsonschema:"id of the connection to run the query against"`
+	SQL          string `json:"sql" jsonschema:"sql code to be executed"`
 }
 
 type QueryOutput struct {
-	Rows []map[string]any `json:"rows"`
+	Rows      []map[string]any `json:"rows"`
+	RowCount  int              `json:"row_count"`
+	Truncated bool             `json:"truncated"`
+	MaxRows   int              `json:"max_rows,omitempty"`
 }
 
-func RunQuery(ctx context.Context, req *mcp.CallToolRequest, input QueryInput) (*mcp.CallToolResult, *QueryOutput, error) {
-	return nil, nil, nil
+func (h *ToolHandler) RunQuery(ctx context.Context, req *mcp.CallToolRequest, input QueryInput) (*mcp.CallToolResult, *QueryOutput, error) {
+	pinoqlClaims, err := claimsFromRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start := time.Now()
+
+	if input.SQL == "" {
+		err := fmt.Errorf("sql is required")
+		h.record(pinoqlClaims, input.ConnectionID, audit.ActionQuery, "", start, nil, err)
+		return nil, nil, err
+	}
+
+	conn, adapter, release, err := h.resolveConnection(ctx, pinoqlClaims, input.ConnectionID)
+	if err != nil {
+		h.record(pinoqlClaims, input.ConnectionID, audit.ActionConnect, input.SQL, start, nil, err)
+		return nil, nil, err
+	}
+	defer release()
+
+	output, err := h.runQuery(ctx, pinoqlClaims, conn, adapter, input.SQL)
+	var rowCount *int
+	if output != nil {
+		rowCount = &output.RowCount
+	}
+	h.record(pinoqlClaims, input.ConnectionID, audit.ActionQuery, input.SQL, start, rowCount, err)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nil, output, nil
+}
+
+func (h *ToolHandler) runQuery(ctx context.Context, pinoqlClaims *claims.PinoQLClaims, conn *connection_data.ConnectionData, adapter adapters.Adapter, sql string) (*QueryOutput, error) {
+	statements, err := classifier.Classify(connection.Dialect(conn.Dialect), sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	if err := authorizeStatements(pinoqlClaims, conn.ReadOnly, statements); err != nil {
+		return nil, err
+	}
+
+	timeout := queryTimeout(conn, pinoqlClaims)
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := adapter.RunQuery(queryCtx, sql)
+	if err != nil {
+		return nil, queryError(queryCtx, "failed to run query", timeout, err)
+	}
+	defer func(rows *adapters.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	maxRows := pinoqlClaims.GetMaxRows()
+	output, err := collectRows(rows, maxRows)
+	if err != nil {
+		return nil, queryError(queryCtx, "failed to read rows", timeout, err)
+	}
+
+	if output.Truncated {
+		// Stop the server from producing rows nobody will read.
+		cancel()
+	}
+
+	return output, nil
+}
+
+// queryError reports deadline expiry explicitly, since drivers surface it
+// with messages like "canceling statement due to user request".
+func queryError(ctx context.Context, msg string, timeout time.Duration, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s: query exceeded the %s timeout", msg, timeout)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// collectRows reads at most maxRows rows (0 means unlimited). When more rows
+// are available it stops scanning and flags the output as truncated, leaving
+// the caller to close the cursor.
+func collectRows(rows *adapters.Rows, maxRows int) (*QueryOutput, error) {
+	output := &QueryOutput{Rows: []map[string]any{}, MaxRows: maxRows}
+
+	for rows.Next() {
+		if maxRows > 0 && len(output.Rows) >= maxRows {
+			output.Truncated = true
+			break
+		}
+
+		row := map[string]any{}
+		if err := rows.MapScan(row); err != nil {
+			return nil, err
+		}
+		output.Rows = append(output.Rows, normalizeRow(row))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	output.RowCount = len(output.Rows)
+	return output, nil
+}
+
+// normalizeRow converts driver byte slices to strings so text columns are
+// serialized as JSON strings instead of base64.
+func normalizeRow(row map[string]any) map[string]any {
+	for column, value := range row {
+		if b, ok := value.([]byte); ok {
+			row[column] = string(b)
+		}
+	}
+	return row
 }
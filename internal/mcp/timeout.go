@@ -0,0 +1,30 @@
+package mcp
+
+import (
+	"time"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/claims"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+)
+
+// DefaultQueryTimeout applies when neither the connection nor the token
+// configures a timeout.
+const DefaultQueryTimeout = 30 * time.Second
+
+// queryTimeout resolves the deadline for a tool call: the connection's
+// timeout replaces the server default, and the token's timeout may only
+// shorten it.
+func queryTimeout(conn *connection_data.ConnectionData, pinoqlClaims *claims.PinoQLClaims) time.Duration {
+	timeout := DefaultQueryTimeout
+	if conn.QueryTimeout > 0 {
+		timeout = time.Duration(conn.QueryTimeout) * time.Second
+	}
+
+	if seconds := pinoqlClaims.GetQueryTimeoutSeconds(); seconds > 0 {
+		if tokenTimeout := time.Duration(seconds) * time.Second; tokenTimeout < timeout {
+			timeout = tokenTimeout
+		}
+	}
+
+	return timeout
+}
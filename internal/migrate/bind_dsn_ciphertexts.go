@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
+	"github.com/CaioMtho/pinoql-mcp/internal/crypto"
+	"github.com/pressly/goose/v3"
+)
+
+// bindDSNCiphertextsVersion re-encrypts DSNs written before they were bound to
+// their tenant and connection IDs through AEAD associated data.
+const bindDSNCiphertextsVersion = 20261016150000
+
+func bindDSNCiphertexts(cm *crypto.CryptoManager) *goose.Migration {
+	return goose.NewGoMigration(
+		bindDSNCiphertextsVersion,
+		&goose.GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error {
+			return reencryptDSNs(ctx, tx, cm, func(string, string) []byte { return nil }, connection_data.AssociatedData)
+		}},
+		&goose.GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error {
+			return reencryptDSNs(ctx, tx, cm, connection_data.AssociatedData, func(string, string) []byte { return nil })
+		}},
+	)
+}
+
+type encryptedDSN struct {
+	id, tenantID, dsn, dek, dekKeyID string
+}
+
+func reencryptDSNs(ctx context.Context, tx *sql.Tx, cm *crypto.CryptoManager, from, to func(tenantID, connectionID string) []byte) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, tenant_id, dsn, dek, dek_key_id FROM connection_data`)
+	if err != nil {
+		return fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	var dsns []encryptedDSN
+	for rows.Next() {
+		var d encryptedDSN
+		if err := rows.Scan(&d.id, &d.tenantID, &d.dsn, &d.dek, &d.dekKeyID); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan connection: %w", err)
+		}
+		dsns = append(dsns, d)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range dsns {
+		plaintext, err := cm.Decrypt(&crypto.Envelope{
+			CiphertextHex: d.dsn,
+			WrappedDEKHex: d.dek,
+			KeyID:         d.dekKeyID,
+		}, from(d.tenantID, d.id))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt DSN of connection %s: %w", d.id, err)
+		}
+
+		envelope, err := cm.Encrypt(plaintext, to(d.tenantID, d.id))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt DSN of connection %s: %w", d.id, err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE connection_data SET dsn = ?, dek = ?, dek_key_id = ? WHERE id = ?`,
+			envelope.CiphertextHex, envelope.WrappedDEKHex, envelope.KeyID, d.id,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update connection %s: %w", d.id, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/CaioMtho/pinoql-mcp/db/migrations"
+	"github.com/CaioMtho/pinoql-mcp/internal/crypto"
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+)
+
+// Migrator applies the embedded SQL migrations together with the Go ones
+// that need the crypto manager. Applied versions are tracked in goose's own
+// goose_db_version table, so databases previously migrated with the goose CLI
+// are picked up where they left off.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+func NewMigrator(db *sqlx.DB, cm *crypto.CryptoManager) (*Migrator, error) {
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db.DB, migrations.FS,
+		goose.WithGoMigrations(bindDSNCiphertexts(cm)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return &Migrator{provider: provider}, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	results, err := m.provider.Up(ctx)
+	for _, result := range results {
+		log.Printf("Applied migration %d in %s", result.Source.Version, result.Duration)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return nil
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	result, err := m.provider.Down(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to roll back migration: %w", err)
+	}
+
+	log.Printf("Rolled back migration %d in %s", result.Source.Version, result.Duration)
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context, w io.Writer) error {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get migration status: %w", err)
+	}
+
+	for _, status := range statuses {
+		appliedAt := "-"
+		if status.State == goose.StateApplied {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		_, _ = fmt.Fprintf(w, "%-8s %-20s %d\n", status.State, appliedAt, status.Source.Version)
+	}
+
+	return nil
+}
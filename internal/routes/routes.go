@@ -3,11 +3,17 @@ package routes
 import (
 	"net/http"
 
+	"github.com/CaioMtho/pinoql-mcp/internal/connection"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/admin"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/apikey"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/audit"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/connection_data"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/middleware"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/oauth"
+	"github.com/CaioMtho/pinoql-mcp/internal/credentials/signing"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/tenant"
 	"github.com/CaioMtho/pinoql-mcp/internal/credentials/token"
+	"github.com/CaioMtho/pinoql-mcp/internal/health"
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,38 +22,73 @@ type RouterConfig struct {
 	TokenHandler          *token.JWTHandler
 	TenantHandler         *tenant.Handler
 	AuditHandler          *audit.Handler
-	AuthMiddleware        *middleware.AuthMiddleware
-	MCPHandler            http.Handler
+	AdminHandler          *admin.Handler
+	APIKeyHandler         *apikey.Handler
+	SigningHandler        *signing.Handler
+	HealthHandler         *health.Handler
+	// OAuthHandler is nil when the MCP authorization flow is disabled.
+	OAuthHandler      *oauth.Handler
+	AuthMiddleware    *middleware.AuthMiddleware
+	MCPHandler        http.Handler
+	ConnectionManager *connection.Manager
 }
 
 func SetupRoutes(r *gin.Engine, cfg *RouterConfig) {
+	r.GET("/.well-known/jwks.json", cfg.SigningHandler.JWKS)
+
+	if cfg.OAuthHandler != nil {
+		for _, path := range []string{"/.well-known/oauth-protected-resource", "/.well-known/oauth-protected-resource/mcp"} {
+			r.GET(path, cfg.OAuthHandler.ProtectedResourceMetadata)
+			r.OPTIONS(path, cfg.OAuthHandler.ProtectedResourceMetadata)
+		}
+		r.GET("/.well-known/oauth-authorization-server", cfg.OAuthHandler.AuthorizationServerMetadata)
+
+		oauthRoutes := r.Group("/oauth")
+		{
+			oauthRoutes.POST("/register", cfg.OAuthHandler.RegisterClient)
+			oauthRoutes.GET("/authorize", cfg.OAuthHandler.Authorize)
+			oauthRoutes.POST("/authorize", cfg.OAuthHandler.Approve)
+			oauthRoutes.POST("/token", cfg.OAuthHandler.Token)
+		}
+	}
+
 	api := r.Group("/api/v1")
 
 	api.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	api.GET("/pools", cfg.AuthMiddleware.RequireSuperAdmin(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, cfg.ConnectionManager.Stats())
+	})
+
 	tenants := api.Group("/tenants")
+	tenants.Use(cfg.AuthMiddleware.RequireSuperAdmin())
 	{
 		tenants.POST("", cfg.TenantHandler.CreateTenant)
 		tenants.GET("", cfg.TenantHandler.ListTenants)
 		tenants.GET("/:id", cfg.TenantHandler.GetTenant)
 		tenants.PUT("/:id", cfg.TenantHandler.UpdateTenant)
 		tenants.DELETE("/:id", cfg.TenantHandler.DeleteTenant)
+		tenants.POST("/:id/admin-tokens", cfg.AdminHandler.IssueToken)
+		tenants.GET("/:id/admin-tokens", cfg.AdminHandler.ListTokens)
+		tenants.DELETE("/:id/admin-tokens/:jti", cfg.AdminHandler.RevokeToken)
 	}
 
 	connections := api.Group("/connections")
-	connections.Use()
+	connections.Use(cfg.AuthMiddleware.RequireTenantAccess(apikey.ScopeConnections))
 	{
 		connections.POST("", cfg.ConnectionDataHandler.CreateConnection)
+		connections.POST("/test", cfg.ConnectionDataHandler.TestConnection)
 		connections.GET("", cfg.ConnectionDataHandler.ListConnections)
 		connections.GET("/:id", cfg.ConnectionDataHandler.GetConnection)
 		connections.PUT("/:id", cfg.ConnectionDataHandler.UpdateConnection)
 		connections.DELETE("/:id", cfg.ConnectionDataHandler.DeleteConnection)
+		connections.GET("/:id/health", cfg.HealthHandler.GetConnectionHealth)
 	}
 
 	jwt := api.Group("/jwt")
-	jwt.Use()
+	jwt.Use(cfg.AuthMiddleware.RequireTenantAccess(apikey.ScopeTokens))
 	{
 		jwt.POST("/issue", cfg.TokenHandler.IssueToken)
 		jwt.POST("/revoke", cfg.TokenHandler.RevokeToken)
@@ -55,15 +96,22 @@ func SetupRoutes(r *gin.Engine, cfg *RouterConfig) {
 	}
 
 	auditRoutes := api.Group("/audit")
-	auditRoutes.Use()
+	auditRoutes.Use(cfg.AuthMiddleware.RequireTenantAccess(apikey.ScopeAudit))
 	{
 		auditRoutes.GET("/logs", cfg.AuditHandler.ListLogs)
 		auditRoutes.GET("/stats", cfg.AuditHandler.GetStats)
 	}
 
+	apiKeys := api.Group("/api-keys")
+	apiKeys.Use(cfg.AuthMiddleware.RequireTenantAdmin())
+	{
+		apiKeys.POST("", cfg.APIKeyHandler.CreateKey)
+		apiKeys.GET("", cfg.APIKeyHandler.ListKeys)
+		apiKeys.DELETE("/:id", cfg.APIKeyHandler.RevokeKey)
+	}
+
 	mcpGroup := r.Group("/mcp")
-	mcpGroup.Use(cfg.AuthMiddleware.RequireAuth())
 	{
-		mcpGroup.Any("", gin.WrapH(cfg.MCPHandler))
+		mcpGroup.Any("", gin.WrapH(cfg.AuthMiddleware.RequireMCPAuth(cfg.MCPHandler)))
 	}
 }